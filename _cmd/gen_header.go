@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+func main() {
+	headersName := []string{
+		"Accept",
+		"Accept-Charset",
+		"Accept-Encoding",
+		"Accept-Language",
+		"Accept-Ranges",
+		"Age",
+		"Allow",
+		"Authorization",
+		"Cache-Control",
+		"Connection",
+		"Content-Disposition",
+		"Content-Encoding",
+		"Content-Language",
+		"Content-Length",
+		"Content-Location",
+		"Content-Range",
+		"Content-Type",
+		"Cookie",
+		"Date",
+		"ETag",
+		"Expect",
+		"Expires",
+		"Forwarded",
+		"From",
+		"Host",
+		"If-Match",
+		"If-Modified-Since",
+		"If-None-Match",
+		"If-Range",
+		"If-Unmodified-Since",
+		"Keep-Alive",
+		"Last-Modified",
+		"Location",
+		"Max-Forwards",
+		"Origin",
+		"Pragma",
+		"Proxy-Authenticate",
+		"Proxy-Authorization",
+		"Range",
+		"Referer",
+		"Retry-After",
+		"Sec-WebSocket-Accept",
+		"Sec-WebSocket-Extensions",
+		"Sec-WebSocket-Key",
+		"Sec-WebSocket-Protocol",
+		"Sec-WebSocket-Version",
+		"Server",
+		"Set-Cookie",
+		"TE",
+		"Trailer",
+		"Transfer-Encoding",
+		"Upgrade",
+		"User-Agent",
+		"Vary",
+		"Via",
+		"WWW-Authenticate",
+		"X-Forwarded-For",
+		"X-Forwarded-Host",
+		"X-Forwarded-Proto",
+		"X-Real-IP",
+		"X-Request-ID"}
+
+	// 常量的名字, Content-Length -> HeaderContentLength
+	headersType := make([]string, len(headersName))
+	for i, name := range headersName {
+		headersType[i] = "Header" + strings.Replace(name, "-", "", -1)
+	}
+
+	var w io.Writer
+	var code bytes.Buffer
+	w = &code
+
+	fmt.Fprint(w, `package httparser
+
+	// Automatically generated, do not modify
+
+	// HeaderID 常见的header field, 解析器识别出来之后, 回调函数里面可以使用p.HeaderID()拿到
+	// 不在列表里面的header field是HeaderUnknown
+	type HeaderID uint8
+
+	const (
+		// HeaderUnknown 不在列表里面的header field
+		HeaderUnknown HeaderID = iota
+	`)
+	for i, typ := range headersType {
+		fmt.Fprintf(w, "// %s 表示%s\n%s\n", typ, headersName[i], typ)
+	}
+	fmt.Fprint(w, ")\n\n")
+
+	fmt.Fprint(w, `// 规范的header field名字
+	var headerTab = []string{
+		HeaderUnknown: "",
+	`)
+	for i, typ := range headersType {
+		fmt.Fprintf(w, "%s: %q,\n", typ, headersName[i])
+	}
+	fmt.Fprint(w, `}
+
+	// String 返回规范的header field名字, HeaderUnknown返回空字符串
+	func (h HeaderID) String() string {
+		if int(h) < len(headerTab) {
+			return headerTab[h]
+		}
+		return ""
+	}
+
+	`)
+
+	// 按照长度和第一个字节(小写)分组, 生成两层switch
+	groups := map[int]map[byte][]int{}
+	for i, name := range headersName {
+		if groups[len(name)] == nil {
+			groups[len(name)] = map[byte][]int{}
+		}
+		c := name[0] | 0x20
+		groups[len(name)][c] = append(groups[len(name)][c], i)
+	}
+
+	lens := make([]int, 0, len(groups))
+	for l := range groups {
+		lens = append(lens, l)
+	}
+	sort.Ints(lens)
+
+	fmt.Fprint(w, `// lookupHeader 根据header field查找HeaderID, 不区分大小写, 没有找到返回HeaderUnknown
+	func lookupHeader(b []byte) HeaderID {
+		switch len(b) {
+	`)
+
+	for _, l := range lens {
+		fmt.Fprintf(w, "case %d:\n", l)
+		fmt.Fprint(w, "switch b[0] | 0x20 {\n")
+
+		first := make([]int, 0, len(groups[l]))
+		for c := range groups[l] {
+			first = append(first, int(c))
+		}
+		sort.Ints(first)
+
+		for _, c := range first {
+			fmt.Fprintf(w, "case '%c':\n", c)
+			for _, i := range groups[l][byte(c)] {
+				fmt.Fprintf(w, `if equalLower(b, "%s") {
+					return %s
+				}
+				`, strings.ToLower(headersName[i]), headersType[i])
+			}
+		}
+		fmt.Fprint(w, "}\n")
+	}
+
+	fmt.Fprint(w, `}
+		return HeaderUnknown
+	}
+	`)
+
+	b, err := format.Source(code.Bytes())
+	if err != nil {
+		panic(err)
+	}
+	os.Stdout.Write(b)
+}
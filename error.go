@@ -0,0 +1,226 @@
+// Copyright 2021 guonaihong. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httparser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode 错误码, 每个错误码对应parser.go里面的一个Err开头的错误
+type ErrorCode uint8
+
+const (
+	// CodeOK 没有错误
+	CodeOK ErrorCode = iota
+	// CodeMethod 对应ErrMethod
+	CodeMethod
+	// CodeStatusLineHTTP 对应ErrStatusLineHTTP
+	CodeStatusLineHTTP
+	// CodeHTTPVersionNum 对应ErrHTTPVersionNum
+	CodeHTTPVersionNum
+	// CodeHeaderOverflow 对应ErrHeaderOverflow
+	CodeHeaderOverflow
+	// CodeNoEndLF 对应ErrNoEndLF
+	CodeNoEndLF
+	// CodeChunkSize 对应ErrChunkSize
+	CodeChunkSize
+	// CodeReqMethod 对应ErrReqMethod
+	CodeReqMethod
+	// CodeRequestLineLF 对应ErrRequestLineLF
+	CodeRequestLineLF
+	// CodeContentLengthOverflow 对应ErrContentLengthOverflow
+	CodeContentLengthOverflow
+	// CodeChunkSizeOverflow 对应ErrChunkSizeOverflow
+	CodeChunkSizeOverflow
+	// CodeContentLength 对应ErrContentLength
+	CodeContentLength
+	// CodeDuplicateContentLength 对应ErrDuplicateContentLength
+	CodeDuplicateContentLength
+	// CodeContentLengthWithTransferEncoding 对应ErrContentLengthWithTransferEncoding
+	CodeContentLengthWithTransferEncoding
+	// CodeChunkedNotFinal 对应ErrChunkedNotFinal
+	CodeChunkedNotFinal
+	// CodeRequestLineHTTP 对应ErrRequestLineHTTP
+	CodeRequestLineHTTP
+	// CodeBareLF 对应ErrBareLF
+	CodeBareLF
+	// CodeSpaceBeforeColon 对应ErrSpaceBeforeColon
+	CodeSpaceBeforeColon
+	// CodeHeaderField 对应ErrHeaderField
+	CodeHeaderField
+	// CodeHeaderValue 对应ErrHeaderValue
+	CodeHeaderValue
+	// CodeStatus 对应ErrStatus
+	CodeStatus
+	// CodeObsFold 对应ErrObsFold
+	CodeObsFold
+	// CodeClosedConnection 对应ErrClosedConnection
+	CodeClosedConnection
+	// CodeUser 对应ErrUser
+	CodeUser
+	// CodeChunkExtension 对应ErrChunkExtension
+	CodeChunkExtension
+	// CodeTrailer 对应ErrTrailer
+	CodeTrailer
+	// CodeInvalidURL 对应ErrInvalidURL
+	CodeInvalidURL
+	// CodeInvalidURLChar 对应ErrInvalidURLChar
+	CodeInvalidURLChar
+	// CodeURLOverflow 对应ErrURLOverflow
+	CodeURLOverflow
+	// CodeHTTPVersionNotSupported 对应ErrHTTPVersionNotSupported
+	CodeHTTPVersionNotSupported
+	// CodeStatusCode 对应ErrStatusCode
+	CodeStatusCode
+	// CodeUnexpectedEOF 对应ErrUnexpectedEOF
+	CodeUnexpectedEOF
+	// CodeRequestLineOverflow 对应ErrRequestLineOverflow
+	CodeRequestLineOverflow
+	// CodeHeaderSectionOverflow 对应ErrHeaderSectionOverflow
+	CodeHeaderSectionOverflow
+	// CodeTooManyHeaders 对应ErrTooManyHeaders
+	CodeTooManyHeaders
+	// CodeBodyOverflow 对应ErrBodyOverflow
+	CodeBodyOverflow
+	// CodeChunkExtensionOverflow 对应ErrChunkExtensionOverflow
+	CodeChunkExtensionOverflow
+	// CodeTrailerOverflow 对应ErrTrailerOverflow
+	CodeTrailerOverflow
+	// CodeMissingHost 对应ErrMissingHost
+	CodeMissingHost
+	// CodeDuplicateHost 对应ErrDuplicateHost
+	CodeDuplicateHost
+	// CodeInvalidHost 对应ErrInvalidHost
+	CodeInvalidHost
+	// CodeExpectation 对应ErrExpectation
+	CodeExpectation
+	// CodeUpgradeProtocol 对应ErrUpgradeProtocol
+	CodeUpgradeProtocol
+)
+
+// 错误码和错误的对应关系
+var errTab = []error{
+	CodeMethod:                            ErrMethod,
+	CodeStatusLineHTTP:                    ErrStatusLineHTTP,
+	CodeHTTPVersionNum:                    ErrHTTPVersionNum,
+	CodeHeaderOverflow:                    ErrHeaderOverflow,
+	CodeNoEndLF:                           ErrNoEndLF,
+	CodeChunkSize:                         ErrChunkSize,
+	CodeReqMethod:                         ErrReqMethod,
+	CodeRequestLineLF:                     ErrRequestLineLF,
+	CodeContentLengthOverflow:             ErrContentLengthOverflow,
+	CodeChunkSizeOverflow:                 ErrChunkSizeOverflow,
+	CodeContentLength:                     ErrContentLength,
+	CodeDuplicateContentLength:            ErrDuplicateContentLength,
+	CodeContentLengthWithTransferEncoding: ErrContentLengthWithTransferEncoding,
+	CodeChunkedNotFinal:                   ErrChunkedNotFinal,
+	CodeRequestLineHTTP:                   ErrRequestLineHTTP,
+	CodeBareLF:                            ErrBareLF,
+	CodeSpaceBeforeColon:                  ErrSpaceBeforeColon,
+	CodeHeaderField:                       ErrHeaderField,
+	CodeHeaderValue:                       ErrHeaderValue,
+	CodeStatus:                            ErrStatus,
+	CodeObsFold:                           ErrObsFold,
+	CodeClosedConnection:                  ErrClosedConnection,
+	CodeUser:                              ErrUser,
+	CodeChunkExtension:                    ErrChunkExtension,
+	CodeTrailer:                           ErrTrailer,
+	CodeInvalidURL:                        ErrInvalidURL,
+	CodeInvalidURLChar:                    ErrInvalidURLChar,
+	CodeURLOverflow:                       ErrURLOverflow,
+	CodeHTTPVersionNotSupported:           ErrHTTPVersionNotSupported,
+	CodeStatusCode:                        ErrStatusCode,
+	CodeUnexpectedEOF:                     ErrUnexpectedEOF,
+	CodeRequestLineOverflow:               ErrRequestLineOverflow,
+	CodeHeaderSectionOverflow:             ErrHeaderSectionOverflow,
+	CodeTooManyHeaders:                    ErrTooManyHeaders,
+	CodeBodyOverflow:                      ErrBodyOverflow,
+	CodeChunkExtensionOverflow:            ErrChunkExtensionOverflow,
+	CodeTrailerOverflow:                   ErrTrailerOverflow,
+	CodeMissingHost:                       ErrMissingHost,
+	CodeDuplicateHost:                     ErrDuplicateHost,
+	CodeInvalidHost:                       ErrInvalidHost,
+	CodeExpectation:                       ErrExpectation,
+	CodeUpgradeProtocol:                   ErrUpgradeProtocol,
+}
+
+// Err 返回错误码对应的错误
+func (c ErrorCode) Err() error {
+	if int(c) < len(errTab) {
+		return errTab[c]
+	}
+	return nil
+}
+
+// HTTPStatus 服务端收到错误的请求时, 可以回复的状态码
+// 超过Limits里面的限制返回413, 414, 431, 其他的解析错误一般返回400, CodeOK和CodeUser返回0
+func (c ErrorCode) HTTPStatus() int {
+	switch c {
+	case CodeOK, CodeUser:
+		return 0
+	case CodeMethod:
+		return 501
+	case CodeRequestLineOverflow, CodeURLOverflow:
+		return 414
+	case CodeHeaderOverflow, CodeHeaderSectionOverflow, CodeTooManyHeaders, CodeTrailerOverflow:
+		return 431
+	case CodeBodyOverflow, CodeContentLengthOverflow:
+		return 413
+	case CodeHTTPVersionNotSupported:
+		return 505
+	case CodeExpectation:
+		return 417
+	}
+	return 400
+}
+
+// errCode 根据错误找到对应的错误码
+func errCode(err error) ErrorCode {
+	for code, e := range errTab {
+		if e != nil && errors.Is(err, e) {
+			return ErrorCode(code)
+		}
+	}
+	return CodeOK
+}
+
+// ParseError Execute返回的错误
+// 可以使用errors.Is(err, ErrXXX)判断具体的错误
+// 也可以使用errors.As拿到错误码, 出错时的状态和偏移量
+type ParseError struct {
+	Code   ErrorCode // 错误码
+	State  string    // 出错时状态机所在的状态
+	Offset int64     // 出错字节的位置, 从解析器收到的第一个字节开始计算
+	Reason string    // 简短的错误原因
+
+	err error // Abort传入的错误
+}
+
+func (e *ParseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("%v: state(%s) offset(%d)", e.Code.Err(), e.State, e.Offset)
+	}
+	return fmt.Sprintf("%v: state(%s) offset(%d): %s", e.Code.Err(), e.State, e.Offset, e.Reason)
+}
+
+// Unwrap 返回错误码对应的错误, errors.Is需要使用
+// 回调函数调用Abort终止解析时, 返回Abort传入的错误
+func (e *ParseError) Unwrap() error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.Code.Err()
+}
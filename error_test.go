@@ -0,0 +1,187 @@
+package httparser
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_ParseError(t *testing.T) {
+	p := New(REQUEST)
+
+	// 第1次送入的数据是正常的, 第2次送入的method是错的
+	data := []byte("GET / HTTP/1.1\r\n\r\n")
+	n, err := p.Execute(&Setting{}, data)
+	if err != nil {
+		t.Fatalf("Execute:%v", err)
+	}
+
+	n2, err := p.Execute(&Setting{}, []byte("XGET / HTTP/1.1\r\n\r\n"))
+	if !errors.Is(err, ErrMethod) {
+		t.Fatalf("err is %v, expect %v", err, ErrMethod)
+	}
+
+	if n2 != 0 {
+		t.Errorf("success is %d, expect 0", n2)
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("err is %T, expect *ParseError", err)
+	}
+
+	if perr.Code != CodeMethod {
+		t.Errorf("code is %d, expect %d", perr.Code, CodeMethod)
+	}
+
+	if perr.State != "startReq" {
+		t.Errorf("state is %s, expect startReq", perr.State)
+	}
+
+	if perr.Offset != int64(n) {
+		t.Errorf("offset is %d, expect %d", perr.Offset, n)
+	}
+
+	if perr.Reason != "XGET" {
+		t.Errorf("reason is %s, expect XGET", perr.Reason)
+	}
+
+	if p.Status() != "dead" {
+		t.Errorf("status is %s, expect dead", p.Status())
+	}
+
+	// 出错之后一直返回同一个错误
+	_, err2 := p.Execute(&Setting{}, data)
+	if err2 != err {
+		t.Errorf("err is %v, expect %v", err2, err)
+	}
+
+	// Reset之后可以继续使用
+	p.Reset()
+	if _, err = p.Execute(&Setting{}, data); err != nil {
+		t.Errorf("Execute:%v", err)
+	}
+}
+
+// 出错之后调用Init, 上一个消息的状态不会影响下一个消息
+func Test_ParseError_Init(t *testing.T) {
+	p := New(REQUEST)
+	_, err := p.Execute(&Setting{}, []byte("POST / HTTP/1.1\r\nContent-Length: 5\r\nX\x00: 1\r\n\r\n"))
+	if err == nil {
+		t.Fatal("err is nil")
+	}
+
+	p.Init(REQUEST)
+	data := []byte("POST / HTTP/1.1\r\nContent-Length: 6\r\n\r\nhello!")
+	if n, err := p.Execute(&Setting{}, data); err != nil || n != len(data) {
+		t.Errorf("n is %d, err is %v", n, err)
+	}
+}
+
+func Test_ParseError_ChunkSize(t *testing.T) {
+	p := New(RESPONSE)
+
+	data := []byte("HTTP/1.1 200 OK\r\n" +
+		"Transfer-Encoding: chunked\r\n\r\n" +
+		"x\r\n")
+
+	n, err := p.Execute(&Setting{}, data)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("err is %v, expect *ParseError", err)
+	}
+
+	if !errors.Is(err, ErrChunkSize) {
+		t.Errorf("err is %v, expect %v", err, ErrChunkSize)
+	}
+
+	if perr.State != "chunkedSizeStart" {
+		t.Errorf("state is %s, expect chunkedSizeStart", perr.State)
+	}
+
+	if n != len(data)-3 || perr.Offset != int64(n) {
+		t.Errorf("success is %d, offset is %d, expect %d", n, perr.Offset, len(data)-3)
+	}
+}
+
+func Test_ErrorCode_Err(t *testing.T) {
+	for code := CodeMethod; int(code) < len(errTab); code++ {
+		err := code.Err()
+		if err == nil {
+			t.Errorf("code %d has no error", code)
+			continue
+		}
+
+		if errCode(err) != code {
+			t.Errorf("errCode(%v) is %d, expect %d", err, errCode(err), code)
+		}
+	}
+}
+
+func Test_ParseError_Abort(t *testing.T) {
+	errTooLarge := errors.New("too large")
+	p := New(REQUEST)
+	data := []byte("POST / HTTP/1.1\r\nContent-Length: 100000\r\n\r\n")
+	setting := &Setting{
+		HeaderValue: func(p *Parser, _ []byte, _ int) {
+			p.Abort(errTooLarge)
+		},
+	}
+
+	n, err := p.Execute(setting, data)
+	if !errors.Is(err, errTooLarge) {
+		t.Fatalf("err is %v, expect %v", err, errTooLarge)
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Code != CodeUser {
+		t.Fatalf("err is %v, expect CodeUser", err)
+	}
+
+	// 停在header value后面
+	if perr.Offset != int64(n) || string(data[n:]) != "\n\r\n" {
+		t.Errorf("offset is %d, success is %d", perr.Offset, n)
+	}
+
+	if _, err2 := p.Execute(setting, data[n:]); err2 != err {
+		t.Errorf("err is %v, expect %v", err2, err)
+	}
+}
+
+// 在HeadersComplete和ExpectContinue里面调用Abort, 不会回调MessageComplete
+func Test_ParseError_AbortHeadersComplete(t *testing.T) {
+	errReject := errors.New("reject")
+	abort := func(p *Parser, _ int) {
+		p.Abort(errReject)
+	}
+
+	for _, tc := range []struct {
+		req     string
+		setting Setting
+	}{
+		{req: "GET / HTTP/1.1\r\nHost: a\r\n\r\n", setting: Setting{HeadersComplete: abort}},
+		{req: "GET / HTTP/1.1\r\nUpgrade: websocket\r\nConnection: upgrade\r\n\r\n", setting: Setting{HeadersComplete: abort}},
+		{req: "POST / HTTP/1.1\r\nContent-Length: 0\r\n\r\n", setting: Setting{HeadersComplete: abort}},
+		{req: "POST / HTTP/1.1\r\nExpect: 100-continue\r\nContent-Length: 5\r\n\r\nhello", setting: Setting{ExpectContinue: abort}},
+	} {
+		complete := 0
+		body := 0
+		setting := tc.setting
+		setting.MessageComplete = func(*Parser, int) {
+			complete++
+		}
+		setting.Body = func(*Parser, []byte, int) {
+			body++
+		}
+
+		p := New(REQUEST)
+		_, err := p.Execute(&setting, []byte(tc.req))
+		var perr *ParseError
+		if !errors.Is(err, errReject) || !errors.As(err, &perr) || perr.State != "headersDone" {
+			t.Errorf("%q: err is %v", tc.req, err)
+		}
+
+		if complete != 0 || body != 0 {
+			t.Errorf("%q: complete is %d, body is %d", tc.req, complete, body)
+		}
+	}
+}
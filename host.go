@@ -0,0 +1,132 @@
+// Copyright 2021 guonaihong. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httparser
+
+import "bytes"
+
+// https://www.rfc-editor.org/rfc/rfc9112#section-3.2
+// A server MUST respond with a 400 (Bad Request) status code to any
+// HTTP/1.1 request message that lacks a Host header field and to any
+// request message that contains more than one Host header field line or
+// a Host header field with an invalid field value.
+
+// setAuthority 记录absolute-form里面的authority, 不包括userinfo
+// 只有CheckHost为true时才会调用
+func (p *Parser) setAuthority(target []byte) {
+	p.hasAuthority = false
+	if p.Method == CONNECT {
+		return
+	}
+
+	// absolute-form = scheme "://" authority path-abempty [ "?" query ]
+	pos := bytes.Index(target, []byte("://"))
+	if pos <= 0 || bytes.IndexByte(target[:pos], '/') != -1 {
+		return
+	}
+
+	authority := target[pos+3:]
+	if end := bytes.IndexAny(authority, "/?#"); end != -1 {
+		authority = authority[:end]
+	}
+
+	if at := bytes.LastIndexByte(authority, '@'); at != -1 {
+		authority = authority[at+1:]
+	}
+
+	p.authority = append(p.authority[:0], authority...)
+	p.hasAuthority = true
+}
+
+// checkHost 检查Host头部的值, 返回错误码和错误原因
+// Host = uri-host [ ":" port ]
+func (p *Parser) checkHost(v []byte) (ErrorCode, string) {
+	v = bytes.Trim(v, " \t")
+	if !validHost(v) {
+		return CodeInvalidHost, string(v)
+	}
+
+	// Host必须和absolute-form里面的authority一样
+	if p.hasAuthority && !bytes.EqualFold(v, p.authority) {
+		return CodeInvalidHost, string(v) + " != " + string(p.authority)
+	}
+
+	return CodeOK, ""
+}
+
+// validHost 检查uri-host [ ":" port ], request-target没有authority时Host可以为空
+// uri-host = IP-literal / IPv4address / reg-name
+func validHost(v []byte) bool {
+	host := v
+	if len(v) > 0 && v[0] == '[' {
+		end := bytes.IndexByte(v, ']')
+		if end == -1 {
+			return false
+		}
+
+		for _, c := range v[1:end] {
+			if !isHex(c) && c != ':' && c != '.' {
+				return false
+			}
+		}
+
+		host = nil
+		v = v[end+1:]
+	} else if pos := bytes.LastIndexByte(v, ':'); pos != -1 {
+		host = v[:pos]
+		v = v[pos:]
+	} else {
+		v = nil
+	}
+
+	// reg-name = *( unreserved / pct-encoded / sub-delims )
+	for _, c := range host {
+		if !isRegName(c) {
+			return false
+		}
+	}
+
+	if len(v) == 0 {
+		return true
+	}
+
+	// port = *DIGIT
+	if v[0] != ':' {
+		return false
+	}
+
+	for _, c := range v[1:] {
+		if !isNum(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(c byte) bool {
+	return unhex[c] != -1
+}
+
+// isRegName unreserved / pct-encoded / sub-delims里面的字符
+func isRegName(c byte) bool {
+	if isAlphaNum(c) || isMark(c) {
+		return true
+	}
+
+	switch c {
+	case '%', '$', '&', '+', ',', ';', '=':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 guonaihong. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httparser
+
+// Flags 宽松模式的开关, 参考llhttp的lenient flags
+// 解析器默认是严格模式, 设置Parser.Lenient对应的位之后, 解析器会接受一些不规范的报文
+// 比如:
+// p := httparser.New(httparser.REQUEST)
+// p.Lenient = httparser.LenientBareLF | httparser.LenientSpaceBeforeColon
+type Flags uint32
+
+const (
+	// LenientHeaderToken 允许header field里面出现tchar以外的字符
+	// https://www.rfc-editor.org/rfc/rfc9110#section-5.1
+	LenientHeaderToken Flags = 1 << iota
+	// LenientBareLF 允许只使用\n作为行结束符号
+	// https://www.rfc-editor.org/rfc/rfc9112#section-2.2
+	LenientBareLF
+	// LenientSpaceBeforeColon 允许header field和':'之间有空白符号
+	// https://www.rfc-editor.org/rfc/rfc9112#section-5.1
+	LenientSpaceBeforeColon
+	// LenientControlChar 允许header value和状态短语里面出现控制字符
+	LenientControlChar
+	// LenientVersion 请求行不检查"HTTP/"
+	LenientVersion
+	// LenientObsFold 允许header value使用obs-fold(以空白开头的续行)
+	// https://www.rfc-editor.org/rfc/rfc9112#section-5.2
+	LenientObsFold
+	// LenientHTTP09 允许HTTP/0.9的请求行, 比如GET /\r\n
+	// 解析器会设置Major = 0, Minor = 9, 请求行结束之后消息就结束了, 连接不能复用
+	LenientHTTP09
+	// LenientUnknownMethod 允许没有注册过的方法, 只要是token就可以
+	// p.Method会被设置成UNKNOWN, 原始的方法名通过Setting.Method回调拿到
+	// https://www.rfc-editor.org/rfc/rfc9110#section-9.1
+	LenientUnknownMethod
+
+	// LenientAll 打开所有的宽松选项
+	LenientAll Flags = 1<<32 - 1
+)
+
+// ValuePolicy header value的字符检查
+// NUL和后面不是\n的\r一直都会被拒绝, 其他的控制字符可以使用LenientControlChar打开
+// HTAB和obs-text(0x80-0xff)默认是允许的, 设置Parser.ValuePolicy对应的位之后会被拒绝
+// 出错时ParseError.Reason是header field的名字
+// https://www.rfc-editor.org/rfc/rfc9110#section-5.5
+type ValuePolicy uint8
+
+const (
+	// RejectHTAB header value里面不允许出现HTAB, 包括value前后的空白
+	RejectHTAB ValuePolicy = 1 << iota
+	// RejectObsText header value里面不允许出现obs-text
+	RejectObsText
+)
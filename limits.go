@@ -0,0 +1,115 @@
+// Copyright 2021 guonaihong. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httparser
+
+// Limits 解析器的长度和个数限制, 每个解析器可以有自己的限制, 0表示不限制
+// 超过限制返回对应的错误, 服务端可以使用ErrorCode.HTTPStatus拿到需要回复的状态码
+// 比如:
+// p := httparser.New(httparser.REQUEST)
+// p.Limits = httparser.DefaultLimits()
+// p.MaxBodySize = 1 << 20
+type Limits struct {
+	MaxRequestLine    int32 // 请求行或者状态行的最大长度, ErrRequestLineOverflow
+	MaxURLSize        int32 // url的最大长度, 0时只有CheckTarget才使用全局变量MaxURLSize, ErrURLOverflow
+	MaxHeaderSize     int32 // 单个header的最大长度, ErrHeaderOverflow
+	MaxHeaderSection  int32 // 起始行加上所有header的最大长度, ErrHeaderSectionOverflow
+	MaxHeaderCount    int32 // header的最大个数, ErrTooManyHeaders
+	MaxBodySize       int64 // body的最大长度, chunked包是所有chunk加起来的长度, ErrBodyOverflow
+	MaxChunkExtension int32 // 一个chunk-size后面所有chunk扩展的最大长度, ErrChunkExtensionOverflow
+	MaxTrailerSize    int32 // 所有trailer的最大长度, ErrTrailerOverflow
+	MaxMessages       int32 // 一次Execute最多解析的消息个数, 达到之后返回ErrTooManyMessages, 不会进入dead状态
+}
+
+// DefaultLimits 解析器默认的限制
+// MaxHeaderSize来自同名的全局变量, 其他的限制默认不打开
+// url的长度默认只在CheckTarget为true时检查, 和以前的版本一样
+func DefaultLimits() Limits {
+	return Limits{
+		MaxHeaderSize: MaxHeaderSize,
+	}
+}
+
+// urlLimit url的最大长度, 0表示不限制
+func (p *Parser) urlLimit() int64 {
+	if p.MaxURLSize > 0 {
+		return int64(p.MaxURLSize)
+	}
+
+	if p.CheckTarget {
+		return int64(MaxURLSize)
+	}
+	return 0
+}
+
+// isStartLine 状态机是否在解析请求行或者状态行
+func isStartLine(s state) bool {
+	return s >= startReq && s <= startReqOrRsp
+}
+
+// isHeaderSection 状态机是否在解析header或者trailer
+func isHeaderSection(s state) bool {
+	return s >= headersDone && s <= headerValueLF
+}
+
+// isChunkExtension 状态机是否在解析chunk扩展
+func isChunkExtension(s state) bool {
+	return s >= chunkedExtBWS && s <= chunkedExtQuotedPair || s == chunkedSizeAlmostDone
+}
+
+// checkLimits Execute结束时检查还没有结束的起始行, url, header和chunk扩展
+// end是Execute收到的最后一个字节的位置, 没有解析的数据也会再送过来, 所以也算在里面
+func (p *Parser) checkLimits(end int64, pos int) error {
+	// 升级之后剩下的数据属于别的协议
+	if p.ReadyUpgradeData() {
+		return nil
+	}
+
+	s := p.currState
+	switch {
+	case isStartLine(s):
+		if limit := p.urlLimit(); s == reqURL && limit > 0 && end-p.urlStart > limit {
+			return p.fail(CodeURLOverflow, s, pos, "")
+		}
+		if p.msgStart != unused && p.MaxRequestLine > 0 && end-p.msgStart > int64(p.MaxRequestLine) {
+			return p.fail(CodeRequestLineOverflow, s, pos, "")
+		}
+		if p.msgStart != unused && p.MaxHeaderSection > 0 && end-p.msgStart > int64(p.MaxHeaderSection) {
+			return p.fail(CodeHeaderSectionOverflow, s, pos, "")
+		}
+	case isHeaderSection(s):
+		return p.checkHeaderSection(end, s, pos)
+	case isChunkExtension(s):
+		if p.extStart != unused && p.MaxChunkExtension > 0 && end-p.extStart > int64(p.MaxChunkExtension) {
+			return p.fail(CodeChunkExtensionOverflow, s, pos, "")
+		}
+	}
+	return nil
+}
+
+// checkHeaderSection 检查header或者trailer的总长度
+// off是当前解析到的位置
+func (p *Parser) checkHeaderSection(off int64, s state, pos int) error {
+	if p.hasTrailing {
+		if p.MaxTrailerSize > 0 && off-p.trailerStart > int64(p.MaxTrailerSize) {
+			return p.fail(CodeTrailerOverflow, s, pos, "")
+		}
+		return nil
+	}
+
+	if p.MaxHeaderSection > 0 && off-p.msgStart > int64(p.MaxHeaderSection) {
+		return p.fail(CodeHeaderSectionOverflow, s, pos, "")
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package httparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// executeByByte 每次只送一个字节, 没有解析的数据和下一个字节一起再送一次
+func executeByByte(p *Parser, setting *Setting, data string) error {
+	var pending []byte
+	for i := 0; i < len(data); i++ {
+		pending = append(pending, data[i])
+		success, err := p.Execute(setting, pending)
+		if err != nil {
+			return err
+		}
+		pending = pending[success:]
+	}
+	return nil
+}
+
+func Test_Limits(t *testing.T) {
+	chunked := "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n"
+	for _, tc := range []struct {
+		name   string
+		typ    ReqOrRsp
+		limits Limits
+		data   string
+		err    error
+	}{
+		{
+			name:   "request line",
+			limits: Limits{MaxRequestLine: 20},
+			data:   "GET /" + strings.Repeat("a", 20) + " HTTP/1.1\r\n\r\n",
+			err:    ErrRequestLineOverflow,
+		},
+		{
+			name:   "request line ok",
+			limits: Limits{MaxRequestLine: 20},
+			data:   "GET /a HTTP/1.1\r\n\r\n",
+		},
+		{
+			name:   "status line",
+			typ:    RESPONSE,
+			limits: Limits{MaxRequestLine: 20},
+			data:   "HTTP/1.1 200 " + strings.Repeat("a", 20) + "\r\nContent-Length: 0\r\n\r\n",
+			err:    ErrRequestLineOverflow,
+		},
+		{
+			name:   "url",
+			limits: Limits{MaxURLSize: 8},
+			data:   "GET /" + strings.Repeat("a", 8) + " HTTP/1.1\r\n\r\n",
+			err:    ErrURLOverflow,
+		},
+		{
+			name:   "header section",
+			limits: Limits{MaxHeaderSection: 40},
+			data:   "GET / HTTP/1.1\r\nHost: a\r\nUser-Agent: " + strings.Repeat("a", 20) + "\r\n\r\n",
+			err:    ErrHeaderSectionOverflow,
+		},
+		{
+			name:   "header count",
+			limits: Limits{MaxHeaderCount: 2},
+			data:   "GET / HTTP/1.1\r\nA: 1\r\nB: 2\r\nC: 3\r\n\r\n",
+			err:    ErrTooManyHeaders,
+		},
+		{
+			name:   "header count ok",
+			limits: Limits{MaxHeaderCount: 2},
+			data:   "GET / HTTP/1.1\r\nA: 1\r\nB: 2\r\n\r\n",
+		},
+		{
+			name:   "content-length body",
+			limits: Limits{MaxBodySize: 4},
+			data:   "POST / HTTP/1.1\r\nContent-Length: 5\r\n\r\nhello",
+			err:    ErrBodyOverflow,
+		},
+		{
+			name:   "chunked body",
+			limits: Limits{MaxBodySize: 8},
+			data:   chunked + "5\r\nhello\r\n5\r\nworld\r\n0\r\n\r\n",
+			err:    ErrBodyOverflow,
+		},
+		{
+			name:   "eof body",
+			typ:    RESPONSE,
+			limits: Limits{MaxBodySize: 4},
+			data:   "HTTP/1.1 200 OK\r\n\r\nhello",
+			err:    ErrBodyOverflow,
+		},
+		{
+			name:   "chunk extension",
+			limits: Limits{MaxChunkExtension: 8},
+			data:   chunked + "5;name=" + strings.Repeat("a", 8) + "\r\nhello\r\n0\r\n\r\n",
+			err:    ErrChunkExtensionOverflow,
+		},
+		{
+			name:   "chunk extension ok",
+			limits: Limits{MaxChunkExtension: 8},
+			data:   chunked + "5;a=b\r\nhello\r\n0\r\n\r\n",
+		},
+		{
+			name:   "trailer",
+			limits: Limits{MaxTrailerSize: 8},
+			data:   chunked + "0\r\nExpires: " + strings.Repeat("a", 8) + "\r\n\r\n",
+			err:    ErrTrailerOverflow,
+		},
+		{
+			name:   "messages",
+			limits: Limits{MaxMessages: 2},
+			data:   strings.Repeat("GET / HTTP/1.1\r\n\r\n", 3),
+			err:    ErrTooManyMessages,
+		},
+		{
+			name:   "one message",
+			limits: Limits{MaxMessages: 1},
+			data:   strings.Repeat("GET / HTTP/1.1\r\n\r\n", 3),
+			err:    ErrTooManyMessages,
+		},
+	} {
+		typ := tc.typ
+		if typ == 0 {
+			typ = REQUEST
+		}
+
+		complete := 0
+		setting := &Setting{MessageComplete: func(*Parser, int) {
+			complete++
+		}}
+
+		p := New(typ)
+		p.Limits = tc.limits
+		success, err := p.Execute(setting, []byte(tc.data))
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: err is %v, expect %v", tc.name, err, tc.err)
+		}
+
+		// ErrTooManyMessages不是解析错误, 剩下的数据一直再送, 直到全部解析完
+		// executeByByte遇到错误就返回了, 所以不测试逐字节的情况
+		if tc.limits.MaxMessages > 0 {
+			for data := tc.data[success:]; len(data) > 0; data = data[success:] {
+				success, err = p.Execute(setting, []byte(data))
+				if err != nil && !errors.Is(err, ErrTooManyMessages) || success == 0 {
+					t.Errorf("%s: resend err is %v, success %d", tc.name, err, success)
+					break
+				}
+			}
+
+			if complete != 3 {
+				t.Errorf("%s: complete is %d, expect 3", tc.name, complete)
+			}
+			continue
+		}
+
+		p = New(typ)
+		p.Limits = tc.limits
+		err = executeByByte(p, &Setting{}, tc.data)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: byte by byte err is %v, expect %v", tc.name, err, tc.err)
+		}
+	}
+}
+
+// 测试默认的限制和http状态码
+func Test_Limits_Default(t *testing.T) {
+	p := New(REQUEST)
+	if p.MaxHeaderSize != MaxHeaderSize || p.MaxURLSize != 0 {
+		t.Errorf("limits is %+v", p.Limits)
+	}
+
+	// 默认不限制url的长度, 打开CheckTarget之后使用全局变量MaxURLSize
+	req := []byte("GET /" + strings.Repeat("a", int(MaxURLSize)) + " HTTP/1.1\r\n\r\n")
+	if _, err := p.Execute(&Setting{}, req); err != nil {
+		t.Errorf("err is %v", err)
+	}
+
+	p = New(REQUEST)
+	p.CheckTarget = true
+	_, err := p.Execute(&Setting{}, req)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Code.HTTPStatus() != 414 {
+		t.Errorf("err is %v, expect 414", err)
+	}
+
+	for code, status := range map[ErrorCode]int{
+		CodeOK:                    0,
+		CodeHeaderSectionOverflow: 431,
+		CodeBodyOverflow:          413,
+		CodeChunkSize:             400,
+	} {
+		if code.HTTPStatus() != status {
+			t.Errorf("%v: status is %d, expect %d", code.Err(), code.HTTPStatus(), status)
+		}
+	}
+}
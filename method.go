@@ -1,5 +1,11 @@
 package httparser
 
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
 // Method 类型 表示http 方法
 type Method int8
 
@@ -74,6 +80,69 @@ const (
 	UNLINK
 )
 
+// UNKNOWN 表示没有注册过的方法
+// 打开LenientUnknownMethod之后, 任何token都可以作为方法, 原始的方法名通过Setting.Method回调拿到
+const UNKNOWN Method = -1
+
+// maxMethod Method能表示的最大值
+const maxMethod Method = 1<<7 - 1
+
+// extMethods RegisterMethod注册的方法, 下标加上UNLINK + 1就是对应的Method
+var extMethods []string
+
+// RegisterMethod 注册一个新的请求方法, 返回对应的Method
+// 只能在init函数里面调用, 不是并发安全的
+// name必须是token, 方法名区分大小写, 已经存在的方法直接返回对应的Method
+// https://www.rfc-editor.org/rfc/rfc9110#section-9.1
+func RegisterMethod(name string) (Method, error) {
+	if !isToken([]byte(name)) {
+		return 0, fmt.Errorf("%w: %q is not a token", ErrMethod, name)
+	}
+
+	if m := lookupMethod([]byte(name)); m != 0 {
+		return m, nil
+	}
+
+	if m := extMethod([]byte(name), false); m != 0 {
+		return m, nil
+	}
+
+	if len(extMethods) >= int(maxMethod-UNLINK) {
+		return 0, fmt.Errorf("%w: too many methods", ErrMethod)
+	}
+
+	extMethods = append(extMethods, name)
+	return UNLINK + Method(len(extMethods)), nil
+}
+
+// extMethod 查找RegisterMethod注册的方法, 没有找到返回0
+// fold为true时不区分大小写
+func extMethod(b []byte, fold bool) Method {
+	s := *(*string)(unsafe.Pointer(&b))
+	for i, name := range extMethods {
+		if s == name || fold && strings.EqualFold(s, name) {
+			return UNLINK + Method(i+1)
+		}
+	}
+	return 0
+}
+
+// lookupMethodFold 不区分大小写查找内置的方法, CheckMethodCase为false时使用
+func lookupMethodFold(b []byte) Method {
+	if len(b) > maxMethodLen {
+		return 0
+	}
+
+	var upper [maxMethodLen]byte
+	for i, c := range b {
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper[i] = c
+	}
+	return lookupMethod(upper[:len(b)])
+}
+
 func (m Method) String() string {
 	switch m {
 	case GET:
@@ -145,6 +214,9 @@ func (m Method) String() string {
 	case UNLINK:
 		return "UNLINK"
 	default:
+		if m > UNLINK && int(m-UNLINK) <= len(extMethods) {
+			return extMethods[m-UNLINK-1]
+		}
 		return "UNKNOWN"
 	}
 }
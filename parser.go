@@ -17,10 +17,8 @@ package httparser
 import (
 	"bytes"
 	"errors"
-	"fmt"
-	"strconv"
+	"math"
 	"strings"
-	"unicode"
 	"unsafe"
 )
 
@@ -41,48 +39,162 @@ var (
 	ErrReqMethod = errors.New("http request wrong method")
 	// ErrRequestLineLF 请求行没有\n
 	ErrRequestLineLF = errors.New("http request line wrong LF")
+	// ErrContentLengthOverflow Content-Length的值超过int64
+	ErrContentLengthOverflow = errors.New("http content-length overflow")
+	// ErrChunkSizeOverflow chunked表示长度的数字超过int64
+	ErrChunkSizeOverflow = errors.New("http chunk size overflow")
+	// ErrContentLength 错误的Content-Length值, 只能由数字组成
+	ErrContentLength = errors.New("http wrong content-length")
+	// ErrDuplicateContentLength 多个Content-Length的值不一样
+	ErrDuplicateContentLength = errors.New("http conflicting content-length values")
+	// ErrContentLengthWithTransferEncoding 请求包同时有Content-Length和Transfer-Encoding
+	ErrContentLengthWithTransferEncoding = errors.New("http request has both content-length and transfer-encoding")
+	// ErrChunkedNotFinal 请求包Transfer-Encoding的最后一个编码不是chunked
+	ErrChunkedNotFinal = errors.New("http chunked is not the final transfer coding")
+	// ErrRequestLineHTTP 请求行的版本号前面不是HTTP/
+	ErrRequestLineHTTP = errors.New("http request line http")
+	// ErrBareLF 行结束符号只有\n, 没有\r
+	ErrBareLF = errors.New("http bare LF without CR")
+	// ErrSpaceBeforeColon header field和':'之间有空白符号
+	ErrSpaceBeforeColon = errors.New("http whitespace between header field and colon")
+	// ErrHeaderField header field里面有非法字符
+	ErrHeaderField = errors.New("http invalid character in header field")
+	// ErrHeaderValue header value里面有控制字符
+	ErrHeaderValue = errors.New("http invalid character in header value")
+	// ErrStatus 状态短语里面有控制字符
+	ErrStatus = errors.New("http invalid character in status")
+	// ErrObsFold header value使用了obs-fold
+	ErrObsFold = errors.New("http obsolete line folding in header value")
+	// ErrClosedConnection 连接不能复用, 但是后面还有数据
+	ErrClosedConnection = errors.New("http data after connection close")
+	// ErrUser 回调函数里面调用了Abort
+	ErrUser = errors.New("http user callback error")
+	// ErrPaused 回调函数里面调用了Pause, 调用Resume之后把剩下的数据再送一次
+	ErrPaused = errors.New("http parser paused")
+	// ErrChunkExtension chunk扩展的格式不对
+	ErrChunkExtension = errors.New("http invalid character in chunk extension")
+	// ErrTrailer trailer字段没有在Trailer头部里面声明
+	ErrTrailer = errors.New("http trailer field not declared in Trailer header")
+	// ErrInvalidURL url的格式不对
+	ErrInvalidURL = errors.New("http invalid url")
+	// ErrInvalidURLChar url里面有控制字符
+	ErrInvalidURLChar = errors.New("http invalid character in url")
+	// ErrURLOverflow url的长度超过MaxURLSize
+	ErrURLOverflow = errors.New("http url overflow")
+	// ErrHTTPVersionNotSupported VersionPolicy不支持的http版本, 服务端可以回复505
+	ErrHTTPVersionNotSupported = errors.New("http version not supported")
+	// ErrStatusCode 状态码不是3位数字
+	ErrStatusCode = errors.New("http invalid status code")
+	// ErrUnexpectedEOF 消息还没有结束, 连接就关闭了
+	ErrUnexpectedEOF = errors.New("http unexpected eof")
+	// ErrRequestLineOverflow 请求行或者状态行的长度超过MaxRequestLine, 服务端可以回复414
+	ErrRequestLineOverflow = errors.New("http request line overflow")
+	// ErrHeaderSectionOverflow 起始行加上所有header的长度超过MaxHeaderSection, 服务端可以回复431
+	ErrHeaderSectionOverflow = errors.New("http header section overflow")
+	// ErrTooManyHeaders header的个数超过MaxHeaderCount, 服务端可以回复431
+	ErrTooManyHeaders = errors.New("http too many headers")
+	// ErrBodyOverflow body的长度超过MaxBodySize, 服务端可以回复413
+	ErrBodyOverflow = errors.New("http body overflow")
+	// ErrChunkExtensionOverflow chunk扩展的长度超过MaxChunkExtension
+	ErrChunkExtensionOverflow = errors.New("http chunk extension overflow")
+	// ErrTrailerOverflow 所有trailer的长度超过MaxTrailerSize, 服务端可以回复431
+	ErrTrailerOverflow = errors.New("http trailer overflow")
+	// ErrTooManyMessages 一次Execute解析的消息个数达到MaxMessages, 不是解析错误, 把剩下的数据再送一次就可以继续解析
+	ErrTooManyMessages = errors.New("http too many messages")
+	// ErrExpectation Expect头部里面有100-continue以外的期望, 只有CheckExpect为true时才返回, 服务端可以回复417
+	ErrExpectation = errors.New("http unsupported expectation")
+	// ErrUpgradeProtocol 101响应的Upgrade头部里面有OfferUpgrade没有提供的协议
+	ErrUpgradeProtocol = errors.New("http upgrade protocol not offered")
+	// ErrMissingHost HTTP/1.1的请求没有Host头部
+	ErrMissingHost = errors.New("http missing host")
+	// ErrDuplicateHost 请求有多个Host头部
+	ErrDuplicateHost = errors.New("http duplicate host")
+	// ErrInvalidHost Host头部的值不合法, 或者和absolute-form里面的authority不一样
+	ErrInvalidHost = errors.New("http invalid host")
 )
 
 var (
-	strTTPslash = []byte("TTP/")
+	strTTPslash  = []byte("TTP/")
+	strHTTPslash = []byte("HTTP/")
+	strICEslash  = []byte("ICE/")
 )
 
 var (
-	bytesCommaSep         = []byte(",")
-	bytesContentLength    = []byte("Content-Length")
-	bytesTransferEncoding = []byte("Transfer-Encoding")
-	bytesChunked          = []byte("chunked")
-	bytesConnection       = []byte("Connection")
-	bytesClose            = []byte("close")
-	bytesUpgrade          = []byte("upgrade")
-	bytesSpace            = []byte(" ")
+	bytesCommaSep  = []byte(",")
+	bytesChunked   = []byte("chunked")
+	bytesClose     = []byte("close")
+	bytesKeepAlive = []byte("keep-alive")
+	bytesUpgrade   = []byte("upgrade")
+	bytesContinue  = []byte("100-continue")
+	bytesSpace     = []byte(" ")
 	// MaxHeaderSize 表示 http header单行最大限制为4k
+	//
+	// Deprecated: 修改全局变量会影响所有的解析器, 使用Parser.Limits, 这里只作为DefaultLimits的默认值
 	MaxHeaderSize int32 = 4096
+	// MaxURLSize 表示 url最大限制为8k, 只有Parser.CheckTarget为true并且没有设置Limits.MaxURLSize时才使用
+	//
+	// Deprecated: 修改全局变量会影响所有的解析器, 使用Parser.Limits
+	MaxURLSize int32 = 8192
 )
 
 const unused = -1
 
 // Parser http 1.1 or http 1.0解析器
 type Parser struct {
-	hType                ReqOrRsp    //解析器的类型，解析请求还是响应
-	Method               Method      //记录request的method
-	currState            state       //记录当前状态
-	headerCurrState      headerState //记录http field状态
-	Major                uint8       //主版本号
-	Minor                uint8       //次版本号
-	MaxHeaderSize        int32       //最大头长度
-	contentLength        int32       //content-length 值
-	StatusCode           uint16      //状态码
-	hasContentLength     bool        //设置Content-Length头部
-	hasTransferEncoding  bool        //transferEncoding头部
-	hasConnectionClose   bool        //Connection: close
-	hasUpgrade           bool        //Upgrade: xx
-	hasConnectionUpgrade bool        //Connection: Upgrade
-	hasTrailing          bool        //有trailer的包
-	callMessageComplete  bool        //记录MessageComplete是否被调用
+	hType                  ReqOrRsp    //解析器的类型，解析请求还是响应
+	msgType                ReqOrRsp    //当前消息的类型, BOTH模式下由解析器根据报文判断
+	Method                 Method      //记录request的method
+	currState              state       //记录当前状态
+	headerCurrState        headerState //记录http field状态
+	headerID               HeaderID    //当前header field对应的HeaderID
+	headerName             []byte      //当前header field的名字, 出错时使用
+	Major                  uint8       //主版本号
+	Minor                  uint8       //次版本号
+	Lenient                Flags       //宽松模式的开关, 默认是严格模式
+	ValuePolicy            ValuePolicy //header value的字符检查
+	CheckTrailer           bool        //只允许出现Trailer头部里面声明过的trailer字段
+	CheckTarget            bool        //检查request-target的字符, 格式和长度
+	CheckHost              bool        //检查请求的Host头部, 个数, 格式和absolute-form里面的authority
+	CheckMethodCase        bool        //方法名区分大小写, 默认get会被当成GET
+	CheckExpect            bool        //Expect头部里面有100-continue以外的期望时返回ErrExpectation
+	hasAuthority           bool        //request-target是带authority的absolute-form
+	hostCount              uint8       //Host头部的个数
+	authority              []byte      //absolute-form里面的authority, 只有CheckHost为true时才记录
+	contentLength          int64       //content-length 值, chunked模式下表示当前chunk剩余的长度
+	StatusCode             uint16      //状态码
+	hasContentLength       bool        //设置Content-Length头部
+	hasTransferEncoding    bool        //transferEncoding头部
+	isChunked              bool        //Transfer-Encoding最后一个编码是chunked
+	hasConnectionClose     bool        //Connection: close
+	hasConnectionKeepAlive bool        //Connection: keep-alive
+	hasUpgrade             bool        //Upgrade: xx
+	hasConnectionUpgrade   bool        //Connection: Upgrade
+	hasTrailing            bool        //有trailer的包
+	hasExpectContinue      bool        //Expect: 100-continue
+	callMessageComplete    bool        //记录MessageComplete是否被调用
+	skipBody               bool        //HeadersComplete回调里面调用了SkipBody, 这个包没有body
+	paused                 bool        //回调函数里面调用了Pause或者Abort
+	abortErr               error       //Abort传入的错误
+	trailers               []string    //Trailer头部声明的字段, 只有CheckTrailer为true时才记录
+	upgrades               []string    //Upgrade头部里面的协议
+	offers                 []string    //OfferUpgrade提供的协议, 用于检查101响应
+	nread                  int64       //已经解析的字节数, 用于计算出错的位置
+	msgStart               int64       //当前消息开始的位置, 用于计算起始行和header的长度
+	urlStart               int64       //url开始的位置
+	extStart               int64       //chunk扩展开始的位置
+	trailerStart           int64       //trailer开始的位置
+	bodySize               int64       //已经收到的body长度
+	headerCount            int32       //header的个数
+	err                    error       //出错之后状态机进入dead状态, 后面的Execute都返回这个错误
 
 	Upgrade bool //从http升级为别的协议, 比如websocket
 
+	Limits //长度和个数的限制, 默认是DefaultLimits()
+
+	// VersionPolicy 返回false表示不支持这个http版本, Execute返回ErrHTTPVersionNotSupported
+	// 为nil时只支持0.x和1.x
+	VersionPolicy func(major, minor uint8) bool
+
 	userData interface{}
 }
 
@@ -93,17 +205,12 @@ func New(t ReqOrRsp) *Parser {
 	return p
 }
 
-// Init 解析器Init函数
+// Init 解析器Init函数, 出错之后也可以调用Init重新开始解析
 func (p *Parser) Init(t ReqOrRsp) {
-
-	p.currState = newState(t)
-
 	p.hType = t
-	p.Major = 0
-	p.Minor = 0
-	p.contentLength = unused
-	p.MaxHeaderSize = MaxHeaderSize
-
+	p.Reset()
+	p.Limits = DefaultLimits()
+	p.nread = 0
 }
 
 // ReadyUpgradeData 如果ReadyUpgradeData为true 说明已经有Upgrade Data数据, 并且http数据已经成功解析完成
@@ -116,6 +223,11 @@ func (p *Parser) complete(s *Setting, pos int) {
 	if s.MessageComplete != nil {
 		s.MessageComplete(p, pos)
 	}
+
+	// 响应解析器的Method只对当前请求的最终响应有效, 1xx中间响应之后还要继续使用
+	if !p.isRequest() && p.StatusClass() != StatusInformational {
+		p.Method = 0
+	}
 }
 
 // SetUserData 的设计出发点和作用
@@ -166,12 +278,112 @@ func (p *Parser) GetUserData() interface{} {
 // 为了适应流量解析的场景，状态机的状态会更碎一点
 
 // Execute 执行解析器
+// 解析出错之后, 解析器进入dead状态, 再调用Execute会一直返回同一个错误, 直到调用Reset或者Init
+// 回调函数里面调用了Pause, Execute停下来并返回ErrPaused, 调用Resume之后把buf[success:]再送一次
+// 解析的消息个数达到MaxMessages, Execute停下来并返回ErrTooManyMessages, 把buf[success:]再送一次
+// 回调函数里面调用了Abort, Execute返回*ParseError, 解析器进入dead状态
 func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+
+	if p.paused {
+		return 0, ErrPaused
+	}
+
+	success, err = p.execute(setting, buf)
+	// 暂停或者终止时还没有解析的数据不算, 恢复之后再送过来时检查
+	if err == nil && !p.paused {
+		err = p.checkLimits(p.nread+int64(len(buf)), success)
+	}
+
+	if err == nil && p.paused {
+		err = ErrPaused
+		if p.abortErr != nil {
+			err = p.abort(success)
+		}
+	}
+
+	p.nread += int64(success)
+	return success, err
+}
+
+// Finish 通知解析器连接已经关闭(读到了EOF)
+// 以连接关闭作为结束的body, 会调用MessageComplete
+// 消息解析到一半连接就关闭了, 返回ErrUnexpectedEOF, 可以使用errors.As拿到出错时的状态
+// Execute返回的success < len(buf)时, 没有再送一次的数据也是不完整的消息, 需要调用者自己判断
+func (p *Parser) Finish(setting *Setting) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	if p.paused {
+		return ErrPaused
+	}
+
+	switch p.currState {
+	case bodyIdentityEOF:
+		p.currState = closed
+		p.complete(setting, 0)
+		return nil
+	case startReq, startRsp, startReqOrRsp, messageDone, closed, dead:
+		// 两个消息之间关闭连接是正常的
+		return nil
+	}
+
+	return p.fail(CodeUnexpectedEOF, p.currState, 0, "")
+}
+
+// Pause 暂停解析, 只能在回调函数里面调用
+// Execute会在当前回调的数据之后停下来, 并返回ErrPaused
+// 同一个字节触发的回调会一起调用完, 比如没有body的包, HeadersComplete之后紧接着调用MessageComplete
+func (p *Parser) Pause() {
+	p.paused = true
+}
+
+// Resume 恢复被Pause暂停的解析器
+func (p *Parser) Resume() {
+	p.paused = false
+}
+
+// Abort 终止解析, 只能在回调函数里面调用
+// Execute返回的*ParseError错误码是CodeUser, 可以使用errors.Is(err, userErr)判断
+func (p *Parser) Abort(err error) {
+	if err == nil {
+		err = ErrUser
+	}
+	p.paused = true
+	p.abortErr = err
+}
+
+// abort 把Abort传入的错误转成*ParseError, 并进入dead状态
+func (p *Parser) abort(pos int) error {
+	p.err = &ParseError{
+		Code:   CodeUser,
+		State:  p.currState.String(),
+		Offset: p.nread + int64(pos),
+		Reason: p.abortErr.Error(),
+		err:    p.abortErr,
+	}
+	p.currState = dead
+	return p.err
+}
+
+// fail 记录错误并进入dead状态
+func (p *Parser) fail(code ErrorCode, s state, pos int, reason string) error {
+	p.err = &ParseError{Code: code, State: s.String(), Offset: p.nread + int64(pos), Reason: reason}
+	p.currState = dead
+	return p.err
+}
+
+func (p *Parser) execute(setting *Setting, buf []byte) (success int, err error) {
 	currState := p.currState
 
 	chunkDataStartIndex := 0
+	chunkExtIndex := 0
 	urlStartIndex := 0
 	reasonPhraseIndex := unused
+	messages := int32(0)
 
 	i := 0
 	c := byte(0)
@@ -187,6 +399,12 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 	}
 
 	for ; i < len(buf); i++ {
+		// 回调函数里面调用了Pause或者Abort, 停在当前字节
+		if p.paused {
+			p.currState = currState
+			return i, nil
+		}
+
 		c = buf[i]
 
 		// fmt.Printf("---->debug state(%s):(%s)method(%#v)\n", currState, buf[i:], p.Method)
@@ -197,13 +415,32 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 				continue
 			}
 
+			p.msgStart = p.nread + int64(i)
+
+			// 以HTTP/开头的是响应包, 其他的都是请求包, 比如HEAD /
 			if c == 'H' {
-				if setting.MessageBegin != nil {
-					setting.MessageBegin(p, i)
+				n := len(buf[i:])
+				if n > len(strHTTPslash) {
+					n = len(strHTTPslash)
+				}
+
+				if bytes.Equal(buf[i:i+n], strHTTPslash[:n]) {
+					// 数据不够判断是不是HTTP/, 等待更多的数据
+					if n < len(strHTTPslash) {
+						p.currState = currState
+						return i, nil
+					}
+
+					if setting.MessageBegin != nil {
+						setting.MessageBegin(p, i)
+					}
+					p.msgType = RESPONSE
+					currState = rspHTTP
+					continue
 				}
-				currState = rspHTTP
-				continue
 			}
+
+			p.msgType = REQUEST
 			currState = startReq
 			fallthrough
 		case startReq:
@@ -211,6 +448,8 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 				continue
 			}
 
+			p.msgStart = p.nread + int64(i)
+
 			pos := bytes.Index(buf[i:], bytesSpace)
 			if pos == -1 {
 				p.currState = startReq
@@ -222,77 +461,26 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 			}
 
 			buf2 := buf[i : i+pos]
-			switch {
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "GET"):
-				p.Method = GET
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "HEAD"):
-				p.Method = HEAD
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "POST"):
-				p.Method = POST
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "PUT"):
-				p.Method = PUT
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "DELETE"):
-				p.Method = DELETE
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "CONNECT"):
-				p.Method = CONNECT
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "OPTIONS"):
-				p.Method = OPTIONS
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "TRACE"):
-				p.Method = TRACE
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "ACL"):
-				p.Method = ACL
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "BIND"):
-				p.Method = BIND
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "COPY"):
-				p.Method = COPY
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "CHECKOUT"):
-				p.Method = CHECKOUT
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "LOCK"):
-				p.Method = LOCK
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "UNLOCK"):
-				p.Method = UNLOCK
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "LINK"):
-				p.Method = LINK
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "MKCOL"):
-				p.Method = MKCOL
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "MOVE"):
-				p.Method = MOVE
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "MKACTIVITY"):
-				p.Method = MKACTIVITY
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "MERGE"):
-				p.Method = MERGE
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "M-SEARCH"):
-				p.Method = MSEARCH
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "MKCALENDAR"):
-				p.Method = MKCALENDAR
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "NOTIFY"):
-				p.Method = NOTIFY
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "PROPFIND"):
-				p.Method = PROPFIND
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "PROPPATCH"):
-				p.Method = PROPPATCH
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "PATCH"):
-				p.Method = PATCH
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "PURGE"):
-				p.Method = PURGE
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "REPORT"):
-				p.Method = REPORT
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "REBIND"):
-				p.Method = REBIND
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "SUBSCRIBE"):
-				p.Method = SUBSCRIBE
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "SEARCH"):
-				p.Method = SEARCH
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "SOURCE"):
-				p.Method = SOURCE
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "UNSUBSCRIBE"):
-				p.Method = UNSUBSCRIBE
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "UNBIND"):
-				p.Method = UNBIND
-			case strings.EqualFold(*(*string)(unsafe.Pointer(&buf2)), "UNLINK"):
-				p.Method = UNLINK
-			default:
-				return 0, fmt.Errorf("%w:%s", ErrMethod, buf2)
+			m := lookupMethod(buf2)
+			if m == 0 && !p.CheckMethodCase {
+				m = lookupMethodFold(buf2)
+			}
+
+			if m == 0 {
+				m = extMethod(buf2, !p.CheckMethodCase)
+			}
+
+			if m == 0 && p.Lenient&LenientUnknownMethod != 0 && isToken(buf2) {
+				m = UNKNOWN
+			}
+
+			if m == 0 {
+				return i, p.fail(CodeMethod, currState, i, string(buf2))
+			}
+			p.Method = m
+
+			if setting.Method != nil {
+				setting.Method(p, buf2, i+pos)
 			}
 
 			i += pos
@@ -302,49 +490,188 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 		case reqMethodAfterSP:
 			if c != ' ' && c != '\t' {
 				urlStartIndex = i
+				p.urlStart = p.nread + int64(i)
 				currState = reqURL
+				goto reExec
 			}
 
 		case reqURL:
+			// 检查request-target和Host时, 需要拿到完整的url
+			if p.CheckTarget || p.CheckHost {
+				end := bytes.IndexAny(buf[i:], " \t\r\n")
+				if end == -1 {
+					if limit := p.urlLimit(); limit > 0 && int64(len(buf[i:])) > limit {
+						return i, p.fail(CodeURLOverflow, currState, i, "")
+					}
+
+					p.currState = reqURL
+					return i, nil
+				}
+
+				if limit := p.urlLimit(); limit > 0 && int64(end) > limit {
+					return i, p.fail(CodeURLOverflow, currState, i, "")
+				}
+
+				if p.CheckTarget {
+					if code, pos := p.checkTarget(buf[i : i+end]); code != CodeOK {
+						return i, p.fail(code, currState, i+pos, "")
+					}
+				}
+
+				if p.CheckHost {
+					p.setAuthority(buf[i : i+end])
+				}
+
+				i += end
+				c = buf[i]
+			}
+
 			if c == ' ' || c == '\t' {
+				if limit := p.urlLimit(); limit > 0 && p.nread+int64(i)-p.urlStart > limit {
+					return i, p.fail(CodeURLOverflow, currState, i, "")
+				}
+
 				currState = reqURLAfterSP
 				if setting.URL != nil {
 					setting.URL(p, buf[urlStartIndex:i], i)
 				}
+				continue
+			}
+
+			// 没有版本号的请求行, 只有HTTP/0.9可以这样
+			if c == '\r' || c == '\n' {
+				if !p.isHTTP09() {
+					return i, p.fail(CodeRequestLineHTTP, currState, i, "")
+				}
+
+				if setting.URL != nil {
+					setting.URL(p, buf[urlStartIndex:i], i)
+				}
+				currState = reqHTTP09
+				goto reExec
 			}
 
 		case reqURLAfterSP:
+			if c == '\r' || c == '\n' {
+				if !p.isHTTP09() {
+					return i, p.fail(CodeRequestLineHTTP, currState, i, "")
+				}
+				currState = reqHTTP09
+				goto reExec
+			}
+
 			if c != ' ' && c != '\t' {
 				currState = reqHTTPVersion
+				goto reExec
+			}
+
+		case reqHTTP09:
+			if c == '\n' && p.Lenient&LenientBareLF == 0 {
+				return i, p.fail(CodeBareLF, currState, i, "")
+			}
+
+			p.Major, p.Minor = 0, 9
+			if !p.versionSupported() {
+				return i, p.fail(CodeHTTPVersionNotSupported, currState, i, "")
+			}
+			currState = reqHTTP09AlmostDone
+			if c == '\r' {
+				continue
+			}
+			goto reExec
+
+		case reqHTTP09AlmostDone:
+			if c != '\n' {
+				return i, p.fail(CodeRequestLineLF, currState, i, "")
 			}
+
+			// https://www.w3.org/Protocols/HTTP/AsImplemented.html
+			// HTTP/0.9没有header和body, 请求行结束消息就结束了
+			if setting.HeadersComplete != nil {
+				setting.HeadersComplete(p, i)
+			}
+			p.complete(setting, i)
+			currState = closed
 		case reqHTTPVersion:
-			if c == '/' {
+			if p.Lenient&LenientVersion != 0 {
+				if c == '/' {
+					currState = reqHTTPVersionMajor
+				}
+				continue
+			}
+
+			if len(buf[i:]) < len(strHTTPslash) {
+				p.currState = currState
+				return i, nil
+			}
+
+			if bytes.Equal(buf[i:i+len(strHTTPslash)], strHTTPslash) {
+				i += len(strHTTPslash) - 1
 				currState = reqHTTPVersionMajor
+				continue
 			}
+
+			// SOURCE方法使用的是icecast协议, 版本号是ICE/1.0
+			if p.Method == SOURCE && bytes.Equal(buf[i:i+len(strICEslash)], strICEslash) {
+				i += len(strICEslash) - 1
+				currState = reqHTTPVersionMajor
+				continue
+			}
+
+			return i, p.fail(CodeRequestLineHTTP, currState, i, "")
+			// https://www.rfc-editor.org/rfc/rfc9112#section-2.3
+			// HTTP-version = HTTP-name "/" DIGIT "." DIGIT
 		case reqHTTPVersionMajor:
+			if !isNum(c) {
+				return i, p.fail(CodeHTTPVersionNum, currState, i, "")
+			}
 			p.Major = c - '0'
 			currState = reqHTTPVersionDot
 		case reqHTTPVersionDot:
+			if c != '.' {
+				return i, p.fail(CodeHTTPVersionNum, currState, i, "")
+			}
 			currState = reqHTTPVersionMinor
 		case reqHTTPVersionMinor:
+			if !isNum(c) {
+				return i, p.fail(CodeHTTPVersionNum, currState, i, "")
+			}
+			p.Minor = c - '0'
+
+			if !p.versionSupported() {
+				return i, p.fail(CodeHTTPVersionNotSupported, currState, i, "")
+			}
+			currState = reqHTTPVersionEnd
+		case reqHTTPVersionEnd:
 			if c == '\r' {
 				currState = reqRequestLineAlomstDone
 				continue
 			}
-			p.Minor = c - '0'
+
+			if c == '\n' {
+				if p.Lenient&LenientBareLF == 0 {
+					return i, p.fail(CodeBareLF, currState, i, "")
+				}
+				currState = headerField
+				continue
+			}
+
+			return i, p.fail(CodeHTTPVersionNum, currState, i, "")
 
 		case reqRequestLineAlomstDone:
 			if c != '\n' {
-				return 0, ErrRequestLineLF
+				return i, p.fail(CodeRequestLineLF, currState, i, "")
 			}
 
 			currState = headerField
 
 		case startRsp:
 			if c != 'H' {
-				return 0, ErrStatusLineHTTP
+				return i, p.fail(CodeStatusLineHTTP, currState, i, "")
 			}
 
+			p.msgStart = p.nread + int64(i)
+
 			if setting.MessageBegin != nil {
 				setting.MessageBegin(p, i)
 			}
@@ -358,44 +685,58 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 			}
 
 			if !bytes.Equal(buf[i:i+len(strTTPslash)], strTTPslash) {
-				return 0, ErrStatusLineHTTP
+				return i, p.fail(CodeStatusLineHTTP, currState, i, "")
 			}
 
 			i += len(strTTPslash) - 1
 			currState = rspHTTPVersionNum
 
 		case rspHTTPVersionNum:
-			// 1.1 or 1.0 or 0.9
-			if len(buf[i:]) < 3 {
+			// 1.1 or 1.0 or 0.9, 版本号后面必须是SP
+			if len(buf[i:]) < 4 {
 				p.currState = currState
 				return i, nil
 			}
 
-			if !unicode.IsNumber(rune(buf[i])) || !unicode.IsNumber(rune(buf[i+2])) {
-				return 0, ErrHTTPVersionNum
+			if !isNum(buf[i]) || buf[i+1] != '.' || !isNum(buf[i+2]) || buf[i+3] != ' ' {
+				return i, p.fail(CodeHTTPVersionNum, currState, i, "")
 			}
 
 			p.Major = buf[i] - '0'
 			p.Minor = buf[i+2] - '0'
-			i += 2 // 3-1
+			if !p.versionSupported() {
+				return i, p.fail(CodeHTTPVersionNotSupported, currState, i, "")
+			}
+
+			i += 3 // 4-1
 			currState = rspHTTPVersionNumAfterSP
 
 		case rspHTTPVersionNumAfterSP:
-			if c == ' ' || c == '\r' || c == '\n' {
+			if c == ' ' {
 				continue
 			}
 
 			currState = rspStatusCode
 			goto reExec
 		case rspStatusCode:
+			// https://www.rfc-editor.org/rfc/rfc9110#section-15
+			// status-code = 3DIGIT, 范围是100-999, 后面是SP或者CRLF
+			if len(buf[i:]) < 4 {
+				p.currState = currState
+				return i, nil
+			}
 
-			if c >= '0' && c <= '9' {
-				p.StatusCode = uint16(int(p.StatusCode)*10 + int(c-'0'))
-				continue
+			if buf[i] < '1' || buf[i] > '9' || !isNum(buf[i+1]) || !isNum(buf[i+2]) {
+				return i, p.fail(CodeStatusCode, currState, i, "")
 			}
 
+			if end := buf[i+3]; end != ' ' && end != '\r' && end != '\n' {
+				return i, p.fail(CodeStatusCode, currState, i+3, "")
+			}
+
+			p.StatusCode = uint16(buf[i]-'0')*100 + uint16(buf[i+1]-'0')*10 + uint16(buf[i+2]-'0')
+			i += 2 // 3-1
 			currState = rspStatusCodeAfterSP
-			goto reExec
 		case rspStatusCodeAfterSP:
 			if c == ' ' {
 				continue
@@ -417,16 +758,38 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 			}
 
 			if c == '\n' {
+				if p.Lenient&LenientBareLF == 0 {
+					return i, p.fail(CodeBareLF, currState, i, "")
+				}
+
 				if setting.Status != nil {
 					setting.Status(p, buf[reasonPhraseIndex:i], i)
 				}
 				currState = headerField
+				continue
+			}
+
+			// reason-phrase = 1*( HTAB / SP / VCHAR / obs-text )
+			if isCtl(c) && p.Lenient&LenientControlChar == 0 {
+				return i, p.fail(CodeStatus, currState, i, "")
 			}
 
 		case rspStatusAfterSP:
+			if c != '\n' {
+				return i, p.fail(CodeNoEndLF, currState, i, "")
+			}
 			currState = headerField
 
 		case headerField:
+			// 第一个header前面是起始行
+			if p.headerCount == 0 && !p.hasTrailing && p.MaxRequestLine > 0 && p.nread+int64(i)-p.msgStart > int64(p.MaxRequestLine) {
+				return i, p.fail(CodeRequestLineOverflow, currState, i, "")
+			}
+
+			if err := p.checkHeaderSection(p.nread+int64(i), currState, i); err != nil {
+				return i, err
+			}
+
 			if c == '\r' {
 				currState = headersDone
 				continue
@@ -434,14 +797,17 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 
 			// 如果http包只使用'\n'作为分隔符号, 将会进入到这个if里面
 			if c == '\n' {
+				if p.Lenient&LenientBareLF == 0 {
+					return i, p.fail(CodeBareLF, currState, i, "")
+				}
 				currState = headersDone
 				goto reExec
 			}
 
 			pos := bytes.IndexByte(buf[i:], ':')
 			if pos == -1 {
-				if int32(len(buf[i:])) > p.MaxHeaderSize {
-					return 0, ErrHeaderOverflow
+				if p.MaxHeaderSize > 0 && int32(len(buf[i:])) > p.MaxHeaderSize {
+					return i, p.fail(CodeHeaderOverflow, currState, i, "")
 				}
 
 				p.currState = headerField
@@ -449,31 +815,77 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 			}
 
 			field := buf[i : i+pos]
+			name := field
+			// https://www.rfc-editor.org/rfc/rfc9112#section-5.1
+			// field name和':'之间不允许有空白符号
+			if pos > 0 && (field[pos-1] == ' ' || field[pos-1] == '\t') {
+				if p.Lenient&LenientSpaceBeforeColon == 0 {
+					return i, p.fail(CodeSpaceBeforeColon, currState, i+pos-1, string(field))
+				}
+				name = bytes.TrimRight(field, " \t")
+			}
+
+			// field-name = token
+			if p.Lenient&LenientHeaderToken == 0 && !isToken(name) {
+				return i, p.fail(CodeHeaderField, currState, i, string(field))
+			}
+
+			p.headerID = lookupHeader(name)
+			p.headerName = append(p.headerName[:0], name...)
+
+			// trailer字段不影响报文的解析, 比如Content-Length, Transfer-Encoding
+			if p.hasTrailing {
+				if p.CheckTrailer && !p.isDeclaredTrailer(name) {
+					return i, p.fail(CodeTrailer, currState, i, string(name))
+				}
+
+				if setting.TrailerField != nil {
+					setting.TrailerField(p, field, i+pos)
+				} else if setting.HeaderField != nil {
+					setting.HeaderField(p, field, i+pos)
+				}
+
+				p.headerCurrState = hGeneral
+				i += pos
+				currState = headerValueDiscardWs
+				continue
+			}
+
+			p.headerCount++
+			if p.MaxHeaderCount > 0 && p.headerCount > p.MaxHeaderCount {
+				return i, p.fail(CodeTooManyHeaders, currState, i, string(name))
+			}
+
+			if p.headerID == HeaderHost && p.CheckHost && p.isRequest() {
+				if p.hostCount++; p.hostCount > 1 {
+					return i, p.fail(CodeDuplicateHost, currState, i, "")
+				}
+			}
+
 			if setting.HeaderField != nil {
 				setting.HeaderField(p, field, i+pos)
 			}
 
-			field = bytes.TrimRight(field, " ")
-			c2 := c | 0x20
-			if c2 == 'c' || c2 == 't' {
-				if bytes.EqualFold(field, bytesContentLength) {
-					// Content-Length
-					p.headerCurrState = hContentLength
-					p.contentLength = 0
-				} else if bytes.EqualFold(field, bytesTransferEncoding) {
-					// Transfer-Encoding
-					p.headerCurrState = hTransferEncoding
-				} else if bytes.EqualFold(field, bytesConnection) {
-					// Connection
-					p.headerCurrState = hConnection
-				} else {
-					// general
-					p.headerCurrState = hGeneral
-				}
-			} else if bytes.EqualFold(field, bytesUpgrade) {
-				p.hasUpgrade = true
+			switch p.headerID {
+			case HeaderContentLength:
+				p.headerCurrState = hContentLength
+			case HeaderTransferEncoding:
+				p.headerCurrState = hTransferEncoding
+			case HeaderConnection:
+				p.headerCurrState = hConnection
+			case HeaderTrailer:
 				p.headerCurrState = hGeneral
-			} else {
+				if p.CheckTrailer {
+					p.headerCurrState = hTrailer
+				}
+			case HeaderUpgrade:
+				p.headerCurrState = hUpgrade
+			case HeaderExpect:
+				p.headerCurrState = hGeneral
+				if p.isRequest() {
+					p.headerCurrState = hExpect
+				}
+			default:
 				p.headerCurrState = hGeneral
 			}
 
@@ -483,6 +895,10 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 			// 只跳过一个' ' or '\t'
 			// 下个状态可能会跳出, 所以这里先把状态刷到parser里面
 			p.currState, currState = headerValue, headerValue
+			if c == '\t' && p.ValuePolicy&RejectHTAB != 0 {
+				return i, p.fail(CodeHeaderValue, currState, i, string(p.headerName))
+			}
+
 			if c == ' ' || c == '\t' {
 				continue
 			}
@@ -492,14 +908,30 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 		case headerValue:
 			end := bytes.IndexAny(buf[i:], "\r\n")
 			if end == -1 {
-				if int32(len(buf[i:])) > p.MaxHeaderSize {
-					return 0, ErrHeaderOverflow
+				if p.MaxHeaderSize > 0 && int32(len(buf[i:])) > p.MaxHeaderSize {
+					return i, p.fail(CodeHeaderOverflow, currState, i, "")
 				}
 				return i, nil
 			}
 
 			hValue := buf[i : i+end]
-			if setting.HeaderValue != nil {
+			if j := p.checkValue(hValue); j != -1 {
+				return i, p.fail(CodeHeaderValue, currState, i+j, string(p.headerName))
+			}
+
+			if p.headerID == HeaderHost && p.CheckHost && p.isRequest() && !p.hasTrailing {
+				if code, reason := p.checkHost(hValue); code != CodeOK {
+					return i, p.fail(code, currState, i, reason)
+				}
+			}
+
+			if p.hasTrailing {
+				if setting.TrailerValue != nil {
+					setting.TrailerValue(p, hValue, i+end)
+				} else if setting.HeaderValue != nil {
+					setting.HeaderValue(p, hValue, i+end)
+				}
+			} else if setting.HeaderValue != nil {
 				setting.HeaderValue(p, hValue, i+end)
 			}
 
@@ -509,32 +941,66 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 				switch p.headerCurrState {
 				case hConnection:
 					switch {
-					case bytes.Contains(hValue, bytesClose):
+					case bytes.EqualFold(hValue, bytesClose):
 						p.hasConnectionClose = true
+					case bytes.EqualFold(hValue, bytesKeepAlive):
+						p.hasConnectionKeepAlive = true
 					case bytes.EqualFold(hValue, bytesUpgrade):
 						p.hasConnectionUpgrade = true
 					}
 				case hContentLength:
-					n, err := strconv.Atoi(BytesToString(bytes.TrimSpace(hValue)))
+					n, err := parseContentLength(hValue)
 					if err != nil {
 						return err
 					}
 
-					p.contentLength = int32(n)
+					// Content-Length: 5, 5 或者多个Content-Length头部, 值必须一样
+					if p.hasContentLength && n != p.contentLength {
+						return ErrDuplicateContentLength
+					}
+
+					p.contentLength = n
 					p.hasContentLength = true
-					p.headerCurrState = hGeneral
 				case hTransferEncoding:
-					pos := bytes.Index(hValue, bytesChunked)
-					// 没有chunked值，归类到通用http header
-					if pos == -1 {
-						p.headerCurrState = hGeneral
+					// 空的list元素直接忽略
+					if len(hValue) == 0 {
+						return nil
 					}
+
+					// 只有最后一个编码是chunked, 才能使用chunked的方式解析body
+					p.isChunked = bytes.EqualFold(hValue, bytesChunked)
 					p.hasTransferEncoding = true
+				case hTrailer:
+					if len(hValue) > 0 {
+						p.trailers = append(p.trailers, string(hValue))
+					}
+				case hUpgrade:
+					// Upgrade = #protocol
+					if len(hValue) > 0 {
+						p.upgrades = append(p.upgrades, string(hValue))
+						p.hasUpgrade = true
+					}
+				case hExpect:
+					// https://www.rfc-editor.org/rfc/rfc9110#section-10.1.1
+					// 100-continue是唯一定义过的期望, 其他的期望服务端可以回复417
+					if len(hValue) == 0 {
+						return nil
+					}
+
+					if bytes.EqualFold(hValue, bytesContinue) {
+						p.hasExpectContinue = true
+						return nil
+					}
+
+					// 默认忽略不认识的期望
+					if p.CheckExpect {
+						return ErrExpectation
+					}
 				}
 				return nil
 			})
 			if err2 != nil {
-				return i, err2
+				return i, p.fail(errCode(err2), currState, i, string(hValue))
 			}
 
 			i += end
@@ -549,66 +1015,154 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 				continue
 			}
 
-			// 不是'\r'的情况，继续往下判断
-			fallthrough
+			// 不是'\r'的情况，就是只有'\n'
+			if p.Lenient&LenientBareLF == 0 {
+				return i, p.fail(CodeBareLF, currState, i, "")
+			}
+
+			currState = headerValueLF
 		case headerValueOWS:
-			currState = headerField
-			if c == '\n' {
-				continue
+			// '\r'后面必须是'\n', value里面单独的'\r'可能会被下游当成换行
+			if c != '\n' {
+				return i, p.fail(CodeHeaderValue, currState, i-1, string(p.headerName))
+			}
+
+			currState = headerValueLF
+		case headerValueLF:
+			// https://www.rfc-editor.org/rfc/rfc9112#section-5.2
+			// obs-fold = OWS CRLF RWS
+			if c != ' ' && c != '\t' {
+				currState = headerField
+				goto reExec
+			}
+
+			if p.Lenient&LenientObsFold == 0 {
+				return i, p.fail(CodeObsFold, currState, i, "")
 			}
 
-			// 不是'\n'就是headerField的数据
+			// Host每一行单独检查都是合法的, 合起来可能不是, 直接拒绝折行的Host
+			if p.headerID == HeaderHost && p.CheckHost && p.isRequest() && !p.hasTrailing {
+				return i, p.fail(CodeInvalidHost, currState, i, "obs-fold")
+			}
+
+			// 续行的内容(包括前面的空白)继续交给HeaderValue回调,
+			// headerCurrState不变, Connection和Transfer-Encoding这些头部会继续处理续行里面的值
+			p.currState, currState = headerValue, headerValue
 			goto reExec
 
 		case headersDone:
 			if c != '\n' {
-				return i, ErrNoEndLF
+				return i, p.fail(CodeNoEndLF, currState, i, "")
+			}
+
+			// trailer解析结束, 整个chunked包也结束了
+			if p.hasTrailing {
+				if setting.TrailersComplete != nil {
+					setting.TrailersComplete(p, i)
+				}
+
+				// 最后一个chunk在trailer之后结束
+				if setting.ChunkComplete != nil {
+					setting.ChunkComplete(p, i)
+				}
+				p.complete(setting, i)
+
+				currState = messageDone
+				goto reExec
+			}
+
+			// HTTP/1.1的请求必须有Host头部
+			if p.CheckHost && p.isRequest() && p.hostCount == 0 && (p.Major > 1 || p.Major == 1 && p.Minor >= 1) {
+				return i, p.fail(CodeMissingHost, currState, i, "")
+			}
+
+			// https://www.rfc-editor.org/rfc/rfc9112#section-6.3
+			// 请求包的body长度有歧义, 可能是请求走私, 直接报错
+			if p.hasTransferEncoding && p.isRequest() {
+				if p.hasContentLength {
+					return i, p.fail(CodeContentLengthWithTransferEncoding, currState, i, "")
+				}
+
+				if !p.isChunked {
+					return i, p.fail(CodeChunkedNotFinal, currState, i, "")
+				}
 			}
 
 			if p.hasUpgrade && p.hasConnectionUpgrade {
-				p.Upgrade = p.hType == REQUEST || p.StatusCode == 101
+				p.Upgrade = p.isRequest() || p.StatusCode == 101
 			} else {
 				//ReadyUpgradeData 函数需要使用
-				p.Upgrade = p.Method == CONNECT
+				// CONNECT的响应只有2xx才会建立隧道
+				p.Upgrade = p.Method == CONNECT && (p.isRequest() || p.StatusClass() == StatusSuccessful)
+			}
+
+			// 101响应选择的协议必须是请求里面提供过的
+			if !p.isRequest() && p.StatusCode == 101 && len(p.offers) > 0 {
+				if proto, ok := p.checkUpgrade(); !ok {
+					return i, p.fail(CodeUpgradeProtocol, currState, i, proto)
+				}
+			}
+
+			// 升级的请求也会回调HeadersComplete, 服务端可以在这里调用AcceptUpgrade
+			if setting.HeadersComplete != nil {
+				setting.HeadersComplete(p, i)
+			}
+
+			// 客户端在等100 Continue, 服务端可以在这里决定回复100, 417或者413
+			if setting.ExpectContinue != nil && p.abortErr == nil && p.ExpectContinue() {
+				setting.ExpectContinue(p, i)
+			}
+
+			// 回调函数里面调用了Abort, 不再解析body, 也不回调MessageComplete
+			if p.abortErr != nil {
+				p.currState = currState
+				return i + 1, nil
 			}
 
 			hasBody := p.hasTransferEncoding || p.hasContentLength && p.contentLength != unused
 
 			//fmt.Printf("p.Upgrade:%t, hasBody:%t, hasTrailing:%t\n", p.Upgrade, hasBody, p.hasTrailing)
-			if p.Upgrade && !hasBody || p.Method == CONNECT {
+			if p.Upgrade && (!hasBody || p.Method == CONNECT) {
 				p.complete(setting, i)
 
 				p.currState = p.newMessage()
 				return i + 1, nil
 			}
 
-			if p.hasTrailing {
-				p.complete(setting, i)
-
+			// 1xx, 204, 304和HEAD请求的响应没有body, 忽略Content-Length和Transfer-Encoding
+			if p.skipBody || !p.isRequest() && p.noBody() {
 				currState = messageDone
-				goto reExec
+				p.complete(setting, i)
+				continue
 			}
 
-			if setting.HeadersComplete != nil {
-				setting.HeadersComplete(p, i)
+			// 响应包同时有Content-Length和Transfer-Encoding, Transfer-Encoding优先
+			if p.hasTransferEncoding {
+				if p.isChunked {
+					currState = chunkedSizeStart
+					continue
+				}
+
+				// 响应包chunked不是最后一个编码, 一直读到socket eof
+				currState = bodyIdentityEOF
+				continue
 			}
 
-			// TODO hasContentLength, hasTransferEncoding同时为true
 			if p.hasContentLength {
+				if p.MaxBodySize > 0 && p.contentLength > p.MaxBodySize {
+					return i, p.fail(CodeBodyOverflow, currState, i, "")
+				}
+
 				// 如果contentLength 等于0，说明body的内容为空，可以直接退出
 				if p.contentLength == 0 {
+					currState = messageDone
 					p.complete(setting, i)
-					return i, nil
+					continue
 				}
 				currState = httpBody
 				continue
 			}
 
-			if p.hasTransferEncoding {
-				currState = chunkedSizeStart
-				continue
-			}
-
 			if p.EOF() {
 				currState = messageDone
 
@@ -619,9 +1173,9 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 			currState = bodyIdentityEOF
 		case httpBody:
 			if p.hasContentLength {
-				nread := min(int32(len(buf[i:])), p.contentLength)
+				nread := min(int64(len(buf[i:])), p.contentLength)
 				if setting.Body != nil && nread > 0 {
-					setting.Body(p, buf[i:int32(i)+nread], i+int(nread))
+					setting.Body(p, buf[i:i+int(nread)], i+int(nread))
 				}
 
 				p.contentLength -= nread
@@ -639,18 +1193,24 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 			}
 
 		case bodyIdentityEOF:
+			p.bodySize += int64(len(buf[i:]))
+			if p.MaxBodySize > 0 && p.bodySize > p.MaxBodySize {
+				return i, p.fail(CodeBodyOverflow, currState, i, "")
+			}
+
 			if setting.Body != nil {
 				setting.Body(p, buf[i:], i+len(buf[i:]))
-				i = len(buf) - 1
 			}
+			i = len(buf) - 1
 
 		case chunkedSizeStart:
 			l := unhex[c]
 			if l == -1 {
-				return 0, ErrChunkSize
+				return i, p.fail(CodeChunkSize, currState, i, "")
 			}
 
-			p.contentLength = int32(l)
+			p.contentLength = int64(l)
+			p.extStart = unused
 			currState = chunkedSize
 
 		case chunkedSize:
@@ -659,29 +1219,165 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 				continue
 			}
 
+			if c == '\n' {
+				if p.Lenient&LenientBareLF == 0 {
+					return i, p.fail(CodeBareLF, currState, i, "")
+				}
+				currState = chunkedSizeAlmostDone
+				goto reExec
+			}
+
 			l := unhex[c]
 			if l == -1 {
-				if c == ';' || c == ' ' {
-					currState = chunkedExt
-					continue
+				if c == ';' || c == ' ' || c == '\t' {
+					p.extStart = p.nread + int64(i)
+					currState = chunkedExtBWS
+					goto reExec
 				}
 
-				return 0, ErrChunkSize
+				return i, p.fail(CodeChunkSize, currState, i, "")
 			}
 
-			p.contentLength = p.contentLength*16 + int32(l)
+			// 再乘16就会超过int64
+			if p.contentLength > (math.MaxInt64-15)/16 {
+				return i, p.fail(CodeChunkSizeOverflow, currState, i, "")
+			}
 
-		case chunkedExt:
-			// 忽略chunked ext
-			if c == '\r' {
+			p.contentLength = p.contentLength*16 + int64(l)
+
+			// https://www.rfc-editor.org/rfc/rfc9112#section-7.1.1
+			// chunk-ext      = *( BWS ";" BWS chunk-ext-name
+			//                     [ BWS "=" BWS chunk-ext-val ] )
+			// chunk-ext-name = token
+			// chunk-ext-val  = token / quoted-string
+		case chunkedExtBWS:
+			switch c {
+			case ' ', '\t':
+			case ';':
+				currState = chunkedExtStart
+			case '\r':
 				currState = chunkedSizeAlmostDone
+			case '\n':
+				if p.Lenient&LenientBareLF == 0 {
+					return i, p.fail(CodeBareLF, currState, i, "")
+				}
+				currState = chunkedSizeAlmostDone
+				goto reExec
+			default:
+				return i, p.fail(CodeChunkExtension, currState, i, "")
+			}
+
+		case chunkedExtStart:
+			if c == ' ' || c == '\t' {
+				continue
+			}
+
+			if token[c] == 0 {
+				return i, p.fail(CodeChunkExtension, currState, i, "")
+			}
+
+			chunkExtIndex = i
+			currState = chunkedExtName
+
+		case chunkedExtName:
+			if token[c] != 0 {
+				continue
+			}
+
+			if setting.ChunkExtensionName != nil {
+				setting.ChunkExtensionName(p, buf[chunkExtIndex:i], i)
+			}
+
+			currState = chunkedExtNameBWS
+			goto reExec
+
+		case chunkedExtNameBWS:
+			// chunk-ext = *( BWS ";" BWS chunk-ext-name [ BWS "=" BWS chunk-ext-val ] )
+			if c == ' ' || c == '\t' {
+				continue
+			}
+
+			if c == '=' {
+				currState = chunkedExtValueStart
+				continue
+			}
+
+			currState = chunkedExtBWS
+			goto reExec
+
+		case chunkedExtValueStart:
+			if c == ' ' || c == '\t' {
+				continue
+			}
+
+			chunkExtIndex = i
+			if c == '"' {
+				currState = chunkedExtQuoted
+				continue
+			}
+
+			if token[c] == 0 {
+				return i, p.fail(CodeChunkExtension, currState, i, "")
+			}
+
+			currState = chunkedExtValue
+
+		case chunkedExtValue:
+			if token[c] != 0 {
+				continue
+			}
+
+			if setting.ChunkExtensionValue != nil {
+				setting.ChunkExtensionValue(p, buf[chunkExtIndex:i], i)
+			}
+
+			currState = chunkedExtBWS
+			goto reExec
+
+		case chunkedExtQuoted:
+			// qdtext = HTAB / SP / %x21 / %x23-5B / %x5D-7E / obs-text
+			switch {
+			case c == '"':
+				if setting.ChunkExtensionValue != nil {
+					setting.ChunkExtensionValue(p, buf[chunkExtIndex:i+1], i+1)
+				}
+				currState = chunkedExtBWS
+			case c == '\\':
+				currState = chunkedExtQuotedPair
+			case isCtl(c):
+				return i, p.fail(CodeChunkExtension, currState, i, "")
+			}
+
+		case chunkedExtQuotedPair:
+			// quoted-pair = "\" ( HTAB / SP / VCHAR / obs-text )
+			if isCtl(c) {
+				return i, p.fail(CodeChunkExtension, currState, i, "")
 			}
+			currState = chunkedExtQuoted
 
 		case chunkedSizeAlmostDone:
+			if c != '\n' {
+				return i, p.fail(CodeNoEndLF, currState, i, "")
+			}
+
+			if p.extStart != unused && p.MaxChunkExtension > 0 && p.nread+int64(i)-p.extStart > int64(p.MaxChunkExtension) {
+				return i, p.fail(CodeChunkExtensionOverflow, currState, i, "")
+			}
+
+			p.bodySize += p.contentLength
+			if p.MaxBodySize > 0 && p.bodySize > p.MaxBodySize {
+				return i, p.fail(CodeBodyOverflow, currState, i, "")
+			}
+
+			if setting.ChunkHeader != nil {
+				setting.ChunkHeader(p, p.contentLength, i)
+			}
+
 			if p.contentLength == 0 {
 
 				// 不管有没有trailing数据包, 先当它有
 				p.hasTrailing = true
+				p.trailerStart = p.nread + int64(i) + 1
 				currState = headerField
 
 				continue
@@ -691,9 +1387,9 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 			currState = chunkedData
 
 		case chunkedData:
-			nread := min(int32(len(buf[i:])), p.contentLength)
+			nread := min(int64(len(buf[i:])), p.contentLength)
 			if setting.Body != nil && nread > 0 {
-				setting.Body(p, buf[chunkDataStartIndex:int32(chunkDataStartIndex)+nread], chunkDataStartIndex+int(nread))
+				setting.Body(p, buf[chunkDataStartIndex:chunkDataStartIndex+int(nread)], chunkDataStartIndex+int(nread))
 			}
 
 			p.contentLength -= nread
@@ -706,8 +1402,26 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 				i += int(nread) - 1
 			}
 		case chunkedDataAlmostDone:
-			currState = chunkedDataDone
+			// chunk data后面必须是\r\n
+			if c == '\r' {
+				currState = chunkedDataDone
+				continue
+			}
+
+			if c == '\n' && p.Lenient&LenientBareLF != 0 {
+				currState = chunkedSizeStart
+				continue
+			}
+
+			return i, p.fail(CodeNoEndLF, currState, i, "")
 		case chunkedDataDone:
+			if c != '\n' {
+				return i, p.fail(CodeNoEndLF, currState, i, "")
+			}
+
+			if setting.ChunkComplete != nil {
+				setting.ChunkComplete(p, i)
+			}
 			currState = chunkedSizeStart
 		case messageDone:
 			// 规范的chunked包是以\r\n结尾的
@@ -715,12 +1429,29 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 				continue
 			}
 			if p.Upgrade {
+				// 剩下的数据属于别的协议, Finish也要知道消息已经结束
+				p.currState = currState
 				return i, nil
 			}
 
+			messages++
+			if p.MaxMessages > 0 && messages >= p.MaxMessages {
+				// 和Pause一样停在下一个消息的开始, 调用者可以把buf[success:]再送一次
+				// 先切换到新消息的状态, 再送过来的消息不会被重复计数
+				p.Reset()
+				return i, ErrTooManyMessages
+			}
+
 			currState = newState(p.hType)
 			p.Reset()
 			goto reExec
+		case closed:
+			// 连接不能复用, 只允许出现多余的\r\n
+			if c == '\r' || c == '\n' {
+				continue
+			}
+
+			return i, p.fail(CodeClosedConnection, currState, i, "")
 		}
 
 	}
@@ -735,6 +1466,16 @@ func (p *Parser) Execute(setting *Setting, buf []byte) (success int, err error)
 		if setting.Status != nil && len(buf[reasonPhraseIndex:]) > 0 {
 			setting.Status(p, buf[reasonPhraseIndex:], len(buf))
 		}
+
+	case chunkedExtName:
+		if setting.ChunkExtensionName != nil && len(buf[chunkExtIndex:]) > 0 {
+			setting.ChunkExtensionName(p, buf[chunkExtIndex:], len(buf))
+		}
+
+	case chunkedExtValue, chunkedExtQuoted, chunkedExtQuotedPair:
+		if setting.ChunkExtensionValue != nil && len(buf[chunkExtIndex:]) > 0 {
+			setting.ChunkExtensionValue(p, buf[chunkExtIndex:], len(buf))
+		}
 	}
 
 	p.currState = currState
@@ -756,7 +1497,9 @@ func newState(t ReqOrRsp) state {
 // Reset 重置状态
 func (p *Parser) Reset() {
 	p.currState = newState(p.hType)
+	p.msgType = p.hType
 	p.headerCurrState = hGeneral
+	p.headerID = HeaderUnknown
 	p.Major = 0
 	p.Minor = 0
 	//p.MaxHeaderSize
@@ -764,12 +1507,27 @@ func (p *Parser) Reset() {
 	p.StatusCode = 0
 	p.hasContentLength = false
 	p.hasTransferEncoding = false
+	p.isChunked = false
 	p.hasConnectionClose = false
+	p.hasConnectionKeepAlive = false
 	p.hasUpgrade = false
 	p.hasConnectionUpgrade = false
 	p.hasTrailing = false
 	p.callMessageComplete = false
+	p.skipBody = false
 	p.Upgrade = false
+	p.err = nil
+	p.paused = false
+	p.abortErr = nil
+	p.trailers = p.trailers[:0]
+	p.msgStart = unused
+	p.extStart = unused
+	p.bodySize = 0
+	p.headerCount = 0
+	p.hostCount = 0
+	p.hasExpectContinue = false
+	p.upgrades = p.upgrades[:0]
+	p.hasAuthority = false
 }
 
 // Status debug专用
@@ -777,38 +1535,252 @@ func (p *Parser) Status() string {
 	return stateTab[p.currState]
 }
 
+// Type 返回当前消息的类型, REQUEST或者RESPONSE
+// BOTH模式下, 还没有判断出消息类型时返回BOTH
+func (p *Parser) Type() ReqOrRsp {
+	return p.msgType
+}
+
+// ExpectContinue 请求是否有Expect: 100-continue, 在HeadersComplete和ExpectContinue回调里面使用
+// HTTP/1.0的请求会忽略100-continue
+// https://www.rfc-editor.org/rfc/rfc9110#section-10.1.1
+func (p *Parser) ExpectContinue() bool {
+	return p.hasExpectContinue && (p.Major > 1 || p.Major == 1 && p.Minor >= 1)
+}
+
+// ContentLength 返回Content-Length的值, 没有Content-Length返回-1
+// 只能在body解析之前使用, 比如HeadersComplete和ExpectContinue回调
+func (p *Parser) ContentLength() int64 {
+	if !p.hasContentLength {
+		return -1
+	}
+	return p.contentLength
+}
+
+// HeaderID 返回当前header field对应的HeaderID, 在HeaderField, HeaderValue和trailer的回调函数里面使用
+// 不在常见header列表里面的field返回HeaderUnknown
+func (p *Parser) HeaderID() HeaderID {
+	return p.headerID
+}
+
+// isRequest 当前解析的是否是请求包
+func (p *Parser) isRequest() bool {
+	return p.msgType == REQUEST
+}
+
+// SkipBody 告诉解析器当前的包没有body, 只能在HeadersComplete回调里面调用
+// 和http-parser里面on_headers_complete返回1(F_SKIPBODY)的作用一样
+// 比如响应解析器不知道对应的请求是HEAD方法时, 可以在HeadersComplete里面调用
+func (p *Parser) SkipBody() {
+	p.skipBody = true
+}
+
+// noBody 根据状态码和请求方法判断响应包有没有body
+// https://www.rfc-editor.org/rfc/rfc9112#section-6.3
+// 响应解析器可以提前设置p.Method为对应请求的方法, HEAD请求的响应没有body
+// 最终响应(非1xx)结束之后p.Method会被清空, 下一个请求的响应需要重新设置
+// 1xx响应不能有body, 101升级响应之后的数据都属于新的协议
+// https://www.rfc-editor.org/rfc/rfc9110#section-15.2
+func (p *Parser) noBody() bool {
+	return p.StatusClass() == StatusInformational ||
+		p.StatusCode == 204 ||
+		p.StatusCode == 304 ||
+		p.Method == HEAD
+}
+
+// isDeclaredTrailer trailer字段是否在Trailer头部里面声明过
+// https://www.rfc-editor.org/rfc/rfc9110#section-6.6.2
+func (p *Parser) isDeclaredTrailer(name []byte) bool {
+	for _, t := range p.trailers {
+		if strings.EqualFold(t, *(*string)(unsafe.Pointer(&name))) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTarget 检查request-target, 返回错误码和出错的位置
+// https://www.rfc-editor.org/rfc/rfc9112#section-3.2
+// request-target = origin-form / absolute-form / authority-form / asterisk-form
+func (p *Parser) checkTarget(target []byte) (ErrorCode, int) {
+	for i, c := range target {
+		if isCtl(c) {
+			return CodeInvalidURLChar, i
+		}
+	}
+
+	// asterisk-form只能用于OPTIONS
+	if len(target) > 0 && target[0] == '*' {
+		if len(target) == 1 && p.Method == OPTIONS {
+			return CodeOK, 0
+		}
+		return CodeInvalidURL, 0
+	}
+
+	// CONNECT只能使用authority-form, 其他方法使用origin-form或者absolute-form
+	if _, err := ParseURL(target, p.Method == CONNECT); err != nil {
+		return CodeInvalidURL, 0
+	}
+
+	return CodeOK, 0
+}
+
+// versionSupported 使用VersionPolicy检查http版本
+// VersionPolicy为nil时, 只支持0.x和1.x, HTTP/2.0这种不能使用http 1.x的方式解析
+func (p *Parser) versionSupported() bool {
+	if p.VersionPolicy != nil {
+		return p.VersionPolicy(p.Major, p.Minor)
+	}
+
+	return p.Major <= 1 || p.Lenient&LenientVersion != 0
+}
+
+// isHTTP09 请求行没有版本号时, 是否按照HTTP/0.9解析
+// HTTP/0.9只有GET方法
+func (p *Parser) isHTTP09() bool {
+	return p.Lenient&LenientHTTP09 != 0 && p.Method == GET
+}
+
 // EOF 表示结束
 func (p *Parser) EOF() bool {
-	if p.hType == REQUEST {
+	if p.isRequest() {
 		return true
 	}
 
 	return p.currState == messageDone
 }
 
-func (p *Parser) shouldKeepAlive() bool {
-	if p.Major > 0 && p.Minor > 0 {
+// ShouldKeepAlive 当前的包结束之后, 连接是否可以复用, 一般在MessageComplete回调里面调用
+// https://www.rfc-editor.org/rfc/rfc9112#section-9.3
+// HTTP/1.1默认是长连接, 除非有Connection: close
+// HTTP/1.0及以前的版本默认是短连接, 除非有Connection: keep-alive
+// body需要读到连接关闭的响应包, 连接不能复用
+func (p *Parser) ShouldKeepAlive() bool {
+	// 升级之后连接交给别的协议使用
+	if p.Upgrade {
+		return true
+	}
+
+	if p.Major > 1 || p.Major == 1 && p.Minor > 0 {
 		if p.hasConnectionClose {
 			return false
 		}
-		//TODO
+	} else if !p.hasConnectionKeepAlive {
+		return false
 	}
 
-	return p.EOF()
+	return !p.needsEOF()
+}
+
+// needsEOF body是否需要读到连接关闭
+// https://www.rfc-editor.org/rfc/rfc9112#section-6.3
+func (p *Parser) needsEOF() bool {
+	if p.isRequest() || p.skipBody || p.noBody() {
+		return false
+	}
+
+	if p.hasTransferEncoding {
+		return !p.isChunked
+	}
+
+	return !p.hasContentLength
 }
 
 func (p *Parser) newMessage() state {
-	if p.shouldKeepAlive() {
-		if p.hType == REQUEST {
-			return startReq
-		}
-		return startRsp
+	if p.ShouldKeepAlive() {
+		return newState(p.hType)
 	}
 
 	return dead
 }
 
-func min(a, b int32) int32 {
+// parseContentLength 解析Content-Length的值
+// Content-Length = 1*DIGIT, 不接受+5, -1, 0x10这种值
+func parseContentLength(b []byte) (int64, error) {
+	if len(b) == 0 {
+		return 0, ErrContentLength
+	}
+
+	n := int64(0)
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, ErrContentLength
+		}
+
+		if n > (math.MaxInt64-int64(c-'0'))/10 {
+			return 0, ErrContentLengthOverflow
+		}
+
+		n = n*10 + int64(c-'0')
+	}
+
+	return n, nil
+}
+
+// isToken 判断是否是合法的token
+// token = 1*tchar
+func isToken(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+
+	for _, c := range b {
+		if token[c] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// equalLower 不区分大小写比较, lower必须是小写
+func equalLower(b []byte, lower string) bool {
+	if len(b) != len(lower) {
+		return false
+	}
+
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if c != lower[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkValue 检查header value里面的字符, 返回出错的位置, 没有错误返回-1
+// field-value = *field-content
+// field-vchar = VCHAR / obs-text
+// https://www.rfc-editor.org/rfc/rfc9110#section-5.5
+func (p *Parser) checkValue(v []byte) int {
+	for j, c := range v {
+		switch {
+		case c == 0:
+			return j
+		case c == '\t':
+			if p.ValuePolicy&RejectHTAB != 0 {
+				return j
+			}
+		case c >= 0x80:
+			if p.ValuePolicy&RejectObsText != 0 {
+				return j
+			}
+		case isCtl(c):
+			if p.Lenient&LenientControlChar == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// isCtl 除了HTAB之外的控制字符
+func isCtl(c byte) bool {
+	return c < ' ' && c != '\t' || c == 0x7f
+}
+
+func min(a, b int64) int64 {
 	if a <= b {
 		return a
 	}
@@ -43,3 +43,20 @@ func Benchmark_Parser(b *testing.B) {
 		p.Reset()
 	}
 }
+
+// 每个方法的请求行解析
+func Benchmark_Parser_Method(b *testing.B) {
+	for m := GET; m <= UNLINK; m++ {
+		data := []byte(m.String() + " / HTTP/1.1\r\nHost: github.com\r\n\r\n")
+		b.Run(m.String(), func(b *testing.B) {
+			p := New(REQUEST)
+			for i := 0; i < b.N; i++ {
+				_, err := p.Execute(&setting, data)
+				if err != nil {
+					panic(err.Error())
+				}
+				p.Reset()
+			}
+		})
+	}
+}
@@ -213,3 +213,41 @@ func Test_ParserResponse_Chunked_Both(t *testing.T) {
 		return
 	}
 }
+
+// 测试BOTH模式判断消息类型, 包括以H开头的方法和被拆开的HTTP/
+func Test_ParserBoth_Type(t *testing.T) {
+	for _, tc := range []struct {
+		msg    string
+		typ    ReqOrRsp
+		method Method
+	}{
+		{msg: "HEAD / HTTP/1.1\r\n\r\n", typ: REQUEST, method: HEAD},
+		{msg: "GET / HTTP/1.1\r\n\r\n", typ: REQUEST, method: GET},
+		{msg: "HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n", typ: RESPONSE},
+	} {
+		p := New(BOTH)
+		if p.Type() != BOTH {
+			t.Errorf("type is %d before parsing", p.Type())
+		}
+
+		typ := ReqOrRsp(0)
+		setting := &Setting{HeadersComplete: func(p *Parser, _ int) {
+			typ = p.Type()
+		}}
+
+		// 每次只送一个字节, 没有解析的数据和下一个字节一起再送一次
+		var pending []byte
+		for i := 0; i < len(tc.msg); i++ {
+			pending = append(pending, tc.msg[i])
+			success, err := p.Execute(setting, pending)
+			if err != nil {
+				t.Fatalf("%q: %v", tc.msg, err)
+			}
+			pending = pending[success:]
+		}
+
+		if typ != tc.typ || p.Method != tc.method {
+			t.Errorf("%q: type is %d, method is %d", tc.msg, typ, p.Method)
+		}
+	}
+}
@@ -22,6 +22,8 @@ import (
 type message struct {
 	name           string
 	hType          ReqOrRsp
+	lenient        Flags
+	checkTarget    bool
 	method         Method
 	raw            string
 	statusCode     int
@@ -44,7 +46,7 @@ type message struct {
 
 	httpMajor     uint16
 	httpMinor     uint16
-	contentLength int32
+	contentLength int64
 
 	messageBeginCbCalled    bool
 	headersCompleteCbCalled bool
@@ -107,6 +109,11 @@ func (m *message) eq(t *testing.T, m2 *message) bool {
 
 	}
 
+	if m.shouldKeepAlive != m2.shouldKeepAlive {
+		t.Errorf("shouldKeepAlive: %v != %v", m.shouldKeepAlive, m2.shouldKeepAlive)
+		return false
+	}
+
 	if m.upgrade != m2.upgrade {
 		t.Errorf("upgrade: %v != %v", m.upgrade, m2.upgrade)
 		return false
@@ -118,8 +125,9 @@ var requests = []message{
 	{
 		name:                    "issue 7 1",
 		hType:                   REQUEST,
+		lenient:                 LenientSpaceBeforeColon,
 		raw:                     "POST /echo HTTP/1.1\r\nHost: localhost:8080\r\nConnection: close \r\nAccept-Encoding : gzip \r\n\r\n",
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -136,8 +144,9 @@ var requests = []message{
 	{
 		name:                    "issue 7 2",
 		hType:                   REQUEST,
+		lenient:                 LenientSpaceBeforeColon,
 		raw:                     "POST /echo HTTP/1.1\r\nHost: localhost:8080\r\nConnection: close \r\nContent-Length :  0\r\nAccept-Encoding : gzip \r\n\r\n",
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -155,8 +164,9 @@ var requests = []message{
 	{
 		name:                    "issue 7 3",
 		hType:                   REQUEST,
+		lenient:                 LenientSpaceBeforeColon,
 		raw:                     "POST /echo HTTP/1.1\r\nHost: localhost:8080\r\nConnection: close \r\nContent-Length :  5\r\nAccept-Encoding : gzip \r\n\r\nhello",
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -347,7 +357,7 @@ var requests = []message{
 			"\r\n" +
 			"HELLO",
 
-		shouldKeepAlive:      true,
+		shouldKeepAlive:      false,
 		messageCompleteOnEOF: false,
 		httpMajor:            1,
 		httpMinor:            0,
@@ -504,7 +514,7 @@ var requests = []message{
 			"User-Agent: ApacheBench/2.3\r\n" +
 			"Accept: */*\r\n\r\n",
 
-		shouldKeepAlive:      true,
+		shouldKeepAlive:      false,
 		messageCompleteOnEOF: false,
 		httpMajor:            1,
 		httpMinor:            0,
@@ -619,23 +629,22 @@ var requests = []message{
 		requestURL:           "/test",
 		contentLength:        unused,
 	},
-	/*
-		{
-			name:                    "request with no http version",
-			hType:                   REQUEST,
-			messageCompleteCbCalled: true,
-			raw: "GET / \r\n" +
-				"\r\n",
+	{
+		name:                    "request with no http version",
+		hType:                   REQUEST,
+		lenient:                 LenientHTTP09,
+		messageCompleteCbCalled: true,
+		raw: "GET / \r\n" +
+			"\r\n",
 
-			shouldKeepAlive:      true,
-			messageCompleteOnEOF: false,
-			httpMajor:            0,
-			httpMinor:            9,
-			//method: HTTP_POST,
-			requestURL:    "/",
-			contentLength: unused,
-		},
-	*/
+		shouldKeepAlive:      false,
+		messageCompleteOnEOF: false,
+		httpMajor:            0,
+		httpMinor:            9,
+		method:               GET,
+		requestURL:           "/",
+		contentLength:        unused,
+	},
 	{
 		name:                    "m-search request",
 		hType:                   REQUEST,
@@ -659,43 +668,43 @@ var requests = []message{
 			{"ST", "\"ssdp:all\""},
 		},
 	},
-	/*
-		{
-			name:                    "line folding in header value",
-			hType:                   REQUEST,
-			messageCompleteCbCalled: true,
-			raw: "GET / HTTP/1.1\r\n" +
-				"Line1:   abc\r\n" +
-				"\tdef\r\n" +
-				" ghi\r\n" +
-				"\t\tjkl\r\n" +
-				"  mno \r\n" +
-				"\t \tqrs\r\n" +
-				"Line2: \t line2\t\r\n" +
-				"Line3:\r\n" +
-				" line3\r\n" +
-				"Line4: \r\n" +
-				" \r\n" +
-				"Connection:\r\n" +
-				" close\r\n" +
-				"\r\n",
+	{
+		name:                    "line folding in header value",
+		hType:                   REQUEST,
+		lenient:                 LenientObsFold,
+		messageCompleteCbCalled: true,
+		raw: "GET / HTTP/1.1\r\n" +
+			"Line1:   abc\r\n" +
+			"\tdef\r\n" +
+			" ghi\r\n" +
+			"\t\tjkl\r\n" +
+			"  mno \r\n" +
+			"\t \tqrs\r\n" +
+			"Line2: \t line2\t\r\n" +
+			"Line3:\r\n" +
+			" line3\r\n" +
+			"Line4: \r\n" +
+			" \r\n" +
+			"Connection:\r\n" +
+			" close\r\n" +
+			"\r\n",
 
-			shouldKeepAlive:      true,
-			messageCompleteOnEOF: false,
-			httpMajor:            1,
-			httpMinor:            1,
-			method:               GET,
-			requestURL:           "/",
-			contentLength:        unused,
-			headers: [][2]string{
-				{"Line1", "abc\tdef ghi\t\tjkl  mno \t \tqrs"},
-				{"Line2", "line2\t"},
-				{"Line3", "line3"},
-				{"Line4", ""},
-				{"Connection", "close"},
-			},
+		shouldKeepAlive:      false,
+		messageCompleteOnEOF: false,
+		httpMajor:            1,
+		httpMinor:            1,
+		method:               GET,
+		requestURL:           "/",
+		contentLength:        unused,
+		headers: [][2]string{
+			// 解析器只跳过value前面的一个空白, 续行会原样交给HeaderValue
+			{"Line1", "  abc\tdef ghi\t\tjkl  mno \t \tqrs"},
+			{"Line2", "\t line2\t"},
+			{"Line3", " line3"},
+			{"Line4", " "},
+			{"Connection", " close"},
 		},
-	*/
+	},
 	{
 		name:                    "host terminated by a query string",
 		hType:                   REQUEST,
@@ -908,42 +917,41 @@ var requests = []message{
 		contentLength:        unused,
 	},
 	//35
-	/*
-		{
-			name:                    "multiple connection header values with folding",
-			hType:                   REQUEST,
-			messageCompleteCbCalled: true,
-			raw: "GET /demo HTTP/1.1\r\n" +
-				"Host: example.com\r\n" +
-				"Connection: Something,\r\n" +
-				" Upgrade, ,Keep-Alive\r\n" +
-				"Sec-WebSocket-Key2: 12998 5 Y3 1  .P00\r\n" +
-				"Sec-WebSocket-Protocol: sample\r\n" +
-				"Upgrade: WebSocket\r\n" +
-				"Sec-WebSocket-Key1: 4 @1  46546xW%0l 1 5\r\n" +
-				"Origin: http://example.com\r\n" +
-				"\r\n" +
-				"Hot diggity dogg",
+	{
+		name:                    "multiple connection header values with folding",
+		hType:                   REQUEST,
+		lenient:                 LenientObsFold,
+		messageCompleteCbCalled: true,
+		raw: "GET /demo HTTP/1.1\r\n" +
+			"Host: example.com\r\n" +
+			"Connection: Something,\r\n" +
+			" Upgrade, ,Keep-Alive\r\n" +
+			"Sec-WebSocket-Key2: 12998 5 Y3 1  .P00\r\n" +
+			"Sec-WebSocket-Protocol: sample\r\n" +
+			"Upgrade: WebSocket\r\n" +
+			"Sec-WebSocket-Key1: 4 @1  46546xW%0l 1 5\r\n" +
+			"Origin: http://example.com\r\n" +
+			"\r\n" +
+			"Hot diggity dogg",
 
-			shouldKeepAlive:      true,
-			messageCompleteOnEOF: false,
-			httpMajor:            1,
-			httpMinor:            1,
-			method:               PATCH,
-			requestURL:           "/demo",
-			contentLength:        unused,
-			upgrade:              "Hot diggity dogg",
-			headers: [][2]string{
-				{"Host", "example.com"},
-				{"Connection", "Something, Upgrade, ,Keep-Alive"},
-				{"Sec-WebSocket-Key2", "12998 5 Y3 1  .P00"},
-				{"Sec-WebSocket-Protocol", "sample"},
-				{"Upgrade", "WebSocket"},
-				{"Sec-WebSocket-Key1", "4 @1  46546xW%0l 1 5"},
-				{"Origin", "http://example.com"},
-			},
+		shouldKeepAlive:      true,
+		messageCompleteOnEOF: false,
+		httpMajor:            1,
+		httpMinor:            1,
+		method:               GET,
+		requestURL:           "/demo",
+		contentLength:        unused,
+		upgrade:              "Hot diggity dogg",
+		headers: [][2]string{
+			{"Host", "example.com"},
+			{"Connection", "Something, Upgrade, ,Keep-Alive"},
+			{"Sec-WebSocket-Key2", "12998 5 Y3 1  .P00"},
+			{"Sec-WebSocket-Protocol", "sample"},
+			{"Upgrade", "WebSocket"},
+			{"Sec-WebSocket-Key1", "4 @1  46546xW%0l 1 5"},
+			{"Origin", "http://example.com"},
 		},
-	*/
+	},
 	// 36
 	{
 		name:                    "multiple connection header values with folding and lws",
@@ -969,31 +977,30 @@ var requests = []message{
 		},
 	},
 	// 37
-	/*
-		{
-			name:                    "multiple connection header values with folding and lws",
-			hType:                   REQUEST,
-			messageCompleteCbCalled: true,
-			raw: "GET /demo HTTP/1.1\r\n" +
-				"Connection: keep-alive, \r\n upgrade\r\n" +
-				"Upgrade: WebSocket\r\n" +
-				"\r\n" +
-				"Hot diggity dogg",
+	{
+		name:                    "multiple connection header values with folding and lws",
+		hType:                   REQUEST,
+		lenient:                 LenientObsFold,
+		messageCompleteCbCalled: true,
+		raw: "GET /demo HTTP/1.1\r\n" +
+			"Connection: keep-alive, \r\n upgrade\r\n" +
+			"Upgrade: WebSocket\r\n" +
+			"\r\n" +
+			"Hot diggity dogg",
 
-			shouldKeepAlive:      true,
-			messageCompleteOnEOF: false,
-			httpMajor:            1,
-			httpMinor:            1,
-			method:               PATCH,
-			requestURL:           "/demo",
-			upgrade:              "Hot diggity dogg",
-			headers: [][2]string{
-				{"Connection", "keep-alive,  upgrade"},
-				{"Upgrade", "WebSocket"},
-			},
-			contentLength: unused,
+		shouldKeepAlive:      true,
+		messageCompleteOnEOF: false,
+		httpMajor:            1,
+		httpMinor:            1,
+		method:               GET,
+		requestURL:           "/demo",
+		upgrade:              "Hot diggity dogg",
+		headers: [][2]string{
+			{"Connection", "keep-alive,  upgrade"},
+			{"Upgrade", "WebSocket"},
 		},
-	*/
+		contentLength: unused,
+	},
 	// 38
 	{
 		name:                    "upgrade post request",
@@ -1125,7 +1132,7 @@ var requests = []message{
 			"Host: example.com\r\n" +
 			"\r\n",
 
-		shouldKeepAlive:      true,
+		shouldKeepAlive:      false,
 		messageCompleteOnEOF: false,
 		httpMajor:            1,
 		httpMinor:            0,
@@ -1285,7 +1292,7 @@ var responses = []message{
 			"  </SOAP-ENV:Body>\n" +
 			"</SOAP-ENV:Envelope>",
 		statusCode:              200,
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -1315,7 +1322,7 @@ var responses = []message{
 		hType:                   RESPONSE,
 		raw:                     "HTTP/1.1 404 Not Found\r\n\r\n",
 		statusCode:              404,
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -1329,7 +1336,7 @@ var responses = []message{
 		hType:                   RESPONSE,
 		raw:                     "HTTP/1.1 301\r\n\r\n",
 		statusCode:              301,
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -1369,8 +1376,9 @@ var responses = []message{
 		},
 	},
 	{
-		name:  "no carriage ret",
-		hType: RESPONSE,
+		name:    "no carriage ret",
+		hType:   RESPONSE,
+		lenient: LenientBareLF,
 		raw: "HTTP/1.1 200 OK\n" +
 			"Content-Type: text/html; charset=utf-8\n" +
 			"Connection: close\n" +
@@ -1378,7 +1386,7 @@ var responses = []message{
 			"these headers are from http://news.ycombinator.com/",
 		statusCode:              200,
 		responseStatus:          "OK",
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -1496,7 +1504,7 @@ var responses = []message{
 			"0\r\n\r\n",
 		statusCode:              200,
 		responseStatus:          "OK",
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -1527,7 +1535,7 @@ var responses = []message{
 			"\r\n",
 		statusCode:              500,
 		responseStatus:          "Oriëntatieprobleem",
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -1547,7 +1555,7 @@ var responses = []message{
 			"\r\n",
 		statusCode:              200,
 		responseStatus:          "OK",
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               0,
@@ -1564,7 +1572,7 @@ var responses = []message{
 			"hello world",
 		statusCode:              200,
 		responseStatus:          "OK",
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -1584,7 +1592,7 @@ var responses = []message{
 			"\r\n",
 		statusCode:              200,
 		responseStatus:          "OK",
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -1621,7 +1629,7 @@ var responses = []message{
 			"\r\n",
 		statusCode:              200,
 		responseStatus:          "OK",
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -1652,7 +1660,7 @@ var responses = []message{
 			"\r\n",
 		statusCode:              204,
 		responseStatus:          "No content",
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -1664,8 +1672,9 @@ var responses = []message{
 		},
 	},
 	{
-		name:  "field space",
-		hType: RESPONSE,
+		name:    "field space",
+		hType:   RESPONSE,
+		lenient: LenientHeaderToken,
 		raw: "HTTP/1.1 200 OK\r\n" +
 			"Server: Microsoft-IIS/6.0\r\n" +
 			"X-Powered-By: ASP.NET\r\n" +
@@ -1743,7 +1752,7 @@ var responses = []message{
 			"\r\n",
 		statusCode:              200,
 		responseStatus:          "",
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -1817,8 +1826,8 @@ var responses = []message{
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
 		httpMinor:               1,
-		upgrade:                 "proto",
-		body:                    "body",
+		// 1xx响应没有body, 101之后的数据都属于新的协议
+		upgrade: "bodyproto",
 		//method: HTTP_GET,
 		contentLength: unused,
 		headers: [][2]string{
@@ -1849,8 +1858,8 @@ var responses = []message{
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
 		httpMinor:               1,
-		upgrade:                 "proto",
-		body:                    "body",
+		// 1xx响应没有body, 101之后的数据都属于新的协议
+		upgrade: "2\r\nbo\r\n2\r\ndy\r\n0\r\n\r\nproto",
 		//method: HTTP_GET,
 		contentLength: unused,
 		headers: [][2]string{
@@ -1869,7 +1878,7 @@ var responses = []message{
 			"body",
 		statusCode:              200,
 		responseStatus:          "OK",
-		shouldKeepAlive:         true,
+		shouldKeepAlive:         false,
 		messageCompleteOnEOF:    false,
 		messageCompleteCbCalled: true,
 		httpMajor:               1,
@@ -1959,7 +1968,8 @@ var settingTest Setting = Setting{
 	},
 	HeaderValue: func(p *Parser, headerValue []byte, _ int) {
 		m := p.GetUserData().(*message)
-		m.headers[len(m.headers)-1][1] = string(headerValue)
+		// obs-fold的续行会再次回调HeaderValue
+		m.headers[len(m.headers)-1][1] += string(headerValue)
 	},
 	HeadersComplete: func(p *Parser, _ int) {
 		m := p.GetUserData().(*message)
@@ -1976,7 +1986,7 @@ var settingTest Setting = Setting{
 		m.statusCode = int(p.StatusCode)
 		m.httpMajor = uint16(p.Major)
 		m.httpMinor = uint16(p.Minor)
-
+		m.shouldKeepAlive = p.ShouldKeepAlive()
 	},
 }
 
@@ -1987,6 +1997,8 @@ func parse(p *Parser, data string) (int, error) {
 func testMessage(t *testing.T, m *message) {
 	for msg1len := 0; msg1len < len(m.raw); msg1len++ {
 		p := New(m.hType)
+		p.Lenient = m.lenient
+		p.CheckTarget = m.checkTarget
 		got := &message{}
 		p.SetUserData(got)
 
@@ -2068,3 +2080,16 @@ func Test_Message(t *testing.T) {
 		_ = rsp
 	}
 }
+
+// 打开CheckTarget, 合法的request-target结果不变
+func Test_Message_CheckTarget(t *testing.T) {
+	for _, req := range requests {
+		// asterisk-form只能用于OPTIONS
+		if req.method == MSEARCH {
+			continue
+		}
+
+		req.checkTarget = true
+		testMessage(t, &req)
+	}
+}
@@ -2,6 +2,7 @@ package httparser
 
 import (
 	"bytes"
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -288,3 +289,249 @@ func Test_ParserResponse_Chunked(t *testing.T) {
 		t.Error("EOF is true, expect false")
 	}
 }
+
+// 响应包同时有Content-Length和Transfer-Encoding, 使用Transfer-Encoding
+// chunked不是最后一个编码, 一直读到eof
+func Test_ParserResponse_TransferEncoding(t *testing.T) {
+	for _, tc := range []struct {
+		rsp  string
+		body string
+	}{
+		{
+			rsp: "HTTP/1.1 200 OK\r\n" +
+				"Content-Length: 100\r\n" +
+				"Transfer-Encoding: chunked\r\n\r\n" +
+				"5\r\nhello\r\n0\r\n\r\n",
+			body: "hello",
+		},
+		{
+			rsp: "HTTP/1.1 200 OK\r\n" +
+				"Transfer-Encoding: chunked, gzip\r\n\r\n" +
+				"5\r\nhello\r\n0\r\n\r\n",
+			body: "5\r\nhello\r\n0\r\n\r\n",
+		},
+	} {
+		p := New(RESPONSE)
+		body := []byte{}
+		setting := &Setting{
+			Body: func(_ *Parser, buf []byte, _ int) {
+				body = append(body, buf...)
+			},
+		}
+
+		_, err := p.Execute(setting, []byte(tc.rsp))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(body) != tc.body {
+			t.Errorf("body is %q, expect %q", body, tc.body)
+		}
+	}
+}
+
+// 测试没有body的响应
+func Test_ParserResponse_NoBody(t *testing.T) {
+	next := "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"
+	for _, tc := range []struct {
+		name     string
+		rsp      string
+		method   Method
+		skipBody bool
+	}{
+		{name: "304", rsp: "HTTP/1.1 304 Not Modified\r\nContent-Length: 5\r\n\r\n"},
+		{name: "204", rsp: "HTTP/1.1 204 No Content\r\nTransfer-Encoding: chunked\r\n\r\n"},
+		{name: "100", rsp: "HTTP/1.1 100 Continue\r\n\r\n"},
+		{name: "103", rsp: "HTTP/1.1 103 Early Hints\r\nLink: </style.css>\r\n\r\n"},
+		{name: "HEAD", rsp: "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\n", method: HEAD},
+		{name: "SkipBody", rsp: "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\n", skipBody: true},
+	} {
+		p := New(RESPONSE)
+		p.Method = tc.method
+		body := []byte{}
+		complete := 0
+		setting := &Setting{
+			HeadersComplete: func(p *Parser, _ int) {
+				if tc.skipBody && complete == 0 {
+					p.SkipBody()
+				}
+			},
+			Body: func(_ *Parser, buf []byte, _ int) {
+				body = append(body, buf...)
+			},
+			MessageComplete: func(p *Parser, _ int) {
+				complete++
+			},
+		}
+
+		// HEAD只对第一个响应有效, 流水线里面的下一个响应还是有body
+		data := tc.rsp + next
+		success, err := p.Execute(setting, []byte(data))
+		if err != nil || success != len(data) {
+			t.Fatalf("%s: err is %v, success %d, expect %d", tc.name, err, success, len(data))
+		}
+
+		if complete != 2 || string(body) != "ok" {
+			t.Errorf("%s: complete is %d, body is %q", tc.name, complete, body)
+		}
+	}
+}
+
+// 测试1xx中间响应之后, HEAD还对最终响应有效
+func Test_ParserResponse_Interim(t *testing.T) {
+	p := New(RESPONSE)
+	p.Method = HEAD
+	complete := 0
+	data := "HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 200 OK\r\nContent-Length: 3\r\n\r\n"
+	success, err := p.Execute(&Setting{MessageComplete: func(*Parser, int) {
+		complete++
+	}}, []byte(data))
+	if err != nil || success != len(data) {
+		t.Fatalf("err is %v, success %d, expect %d", err, success, len(data))
+	}
+
+	if complete != 2 || p.Status() != "messageDone" || p.Method != 0 {
+		t.Errorf("complete is %d, status is %s, method is %s", complete, p.Status(), p.Method)
+	}
+}
+
+// 测试CONNECT请求的响应, 只有2xx才会建立隧道
+func Test_ParserResponse_Connect(t *testing.T) {
+	for _, tc := range []struct {
+		rsp     string
+		upgrade bool
+		body    string
+	}{
+		{rsp: "HTTP/1.1 200 Connection Established\r\n\r\n", upgrade: true},
+		{rsp: "HTTP/1.1 407 Proxy Authentication Required\r\nContent-Length: 2\r\n\r\nno", body: "no"},
+	} {
+		p := New(RESPONSE)
+		p.Method = CONNECT
+		body := []byte{}
+		_, err := p.Execute(&Setting{Body: func(_ *Parser, buf []byte, _ int) {
+			body = append(body, buf...)
+		}}, []byte(tc.rsp))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if p.Upgrade != tc.upgrade || string(body) != tc.body {
+			t.Errorf("upgrade is %t, body is %q", p.Upgrade, body)
+		}
+	}
+}
+
+func Test_ParserResponse_Version(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		err  error
+	}{
+		{line: "HTTP/1.1 200 OK", err: nil},
+		{line: "HXTP/1.1 200 OK", err: ErrStatusLineHTTP},
+		{line: "HTTP/1x1 200 OK", err: ErrHTTPVersionNum},
+		{line: "HTTP/1.x 200 OK", err: ErrHTTPVersionNum},
+		{line: "HTTP/1.10 200 OK", err: ErrHTTPVersionNum},
+		{line: "HTTP/2.0 200 OK", err: ErrHTTPVersionNotSupported},
+	} {
+		p := New(RESPONSE)
+		_, err := p.Execute(&Setting{}, []byte(tc.line+"\r\nContent-Length: 0\r\n\r\n"))
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%q: err is %v, expect %v", tc.line, err, tc.err)
+		}
+	}
+}
+
+// 测试响应包结束之后连接是否可以复用
+func Test_ParserResponse_KeepAlive(t *testing.T) {
+	for _, tc := range []struct {
+		rsp       string
+		keepAlive bool
+	}{
+		{rsp: "HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n", keepAlive: true},
+		{rsp: "HTTP/1.1 200 OK\r\nConnection: close\r\nContent-Length: 0\r\n\r\n", keepAlive: false},
+		{rsp: "HTTP/1.1 200 OK\r\nConnection: Keep-Alive, Close\r\nContent-Length: 0\r\n\r\n", keepAlive: false},
+		{rsp: "HTTP/1.0 200 OK\r\nContent-Length: 0\r\n\r\n", keepAlive: false},
+		{rsp: "HTTP/1.0 200 OK\r\nConnection: keep-alive\r\nContent-Length: 0\r\n\r\n", keepAlive: true},
+		{rsp: "HTTP/1.1 204 No Content\r\n\r\n", keepAlive: true},
+		{rsp: "HTTP/1.1 101 Switching Protocols\r\nConnection: upgrade\r\nUpgrade: websocket\r\n\r\n", keepAlive: true},
+	} {
+		p := New(RESPONSE)
+		called := false
+		_, err := p.Execute(&Setting{MessageComplete: func(p *Parser, _ int) {
+			called = true
+			if p.ShouldKeepAlive() != tc.keepAlive {
+				t.Errorf("%q: keep-alive is %t", tc.rsp, p.ShouldKeepAlive())
+			}
+		}}, []byte(tc.rsp))
+		if err != nil || !called {
+			t.Errorf("%q: err is %v, called %t", tc.rsp, err, called)
+		}
+	}
+
+	// 没有Content-Length和Transfer-Encoding的响应以连接关闭作为结束
+	p := New(RESPONSE)
+	_, err := p.Execute(&Setting{}, []byte("HTTP/1.1 200 OK\r\n\r\nhello"))
+	if err != nil || p.ShouldKeepAlive() {
+		t.Errorf("err is %v, keep-alive is %t", err, p.ShouldKeepAlive())
+	}
+}
+
+// 测试连接关闭作为body的结束
+func Test_ParserResponse_Finish(t *testing.T) {
+	p := New(RESPONSE)
+	complete := 0
+	setting := &Setting{MessageComplete: func(*Parser, int) {
+		complete++
+	}}
+
+	_, err := p.Execute(setting, []byte("HTTP/1.1 200 OK\r\n\r\nhello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = p.Finish(setting); err != nil || complete != 1 {
+		t.Errorf("err is %v, complete is %d", err, complete)
+	}
+
+	// 关闭之后的数据返回错误
+	if _, err = p.Execute(setting, []byte("world")); !errors.Is(err, ErrClosedConnection) {
+		t.Errorf("err is %v, expect %v", err, ErrClosedConnection)
+	}
+
+	p = New(RESPONSE)
+	_, err = p.Execute(setting, []byte("HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nhel"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = p.Finish(setting); !errors.Is(err, ErrUnexpectedEOF) {
+		t.Errorf("err is %v, expect %v", err, ErrUnexpectedEOF)
+	}
+}
+
+// 测试101响应选择的协议必须是OfferUpgrade提供过的
+func Test_ParserResponse_Upgrade(t *testing.T) {
+	for _, tc := range []struct {
+		offers []string
+		rsp    string
+		err    error
+	}{
+		{offers: nil, rsp: "Upgrade: foo\r\nConnection: upgrade\r\n"},
+		{offers: []string{"websocket"}, rsp: "Upgrade: WebSocket\r\nConnection: upgrade\r\n"},
+		{offers: []string{"h2c", "websocket"}, rsp: "Upgrade: websocket\r\nConnection: upgrade\r\n"},
+		{offers: []string{"websocket"}, rsp: "Upgrade: h2c\r\nConnection: upgrade\r\n", err: ErrUpgradeProtocol},
+		{offers: []string{"websocket"}, rsp: "Connection: upgrade\r\n", err: ErrUpgradeProtocol},
+	} {
+		p := New(RESPONSE)
+		p.OfferUpgrade(tc.offers...)
+		_, err := p.Execute(&Setting{}, []byte("HTTP/1.1 101 Switching Protocols\r\n"+tc.rsp+"\r\n"))
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%q: err is %v, expect %v", tc.rsp, err, tc.err)
+			continue
+		}
+
+		if tc.err == nil && (!p.Upgrade || len(p.UpgradeProtocols()) != 1) {
+			t.Errorf("%q: upgrade is %t, protocols is %q", tc.rsp, p.Upgrade, p.UpgradeProtocols())
+		}
+	}
+}
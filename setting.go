@@ -15,9 +15,13 @@
 package httparser
 
 // Setting 查阅#6 看设计变更原因
+// 回调函数里面可以调用Parser的Pause, Abort, SkipBody改变解析的流程
 type Setting struct {
 	// 解析开始
 	MessageBegin func(*Parser, int)
+	// 请求方法的原始数据, 只有在request包才会回调
+	// 没有注册过的方法, p.Method是UNKNOWN, 可以在这里拿到方法名
+	Method func(*Parser, []byte, int)
 	// url 回调函数, 只有在request包才会回调
 	// 解析一个包时,URL回调可能会多次调用
 	URL func(*Parser, []byte, int)
@@ -30,8 +34,28 @@ type Setting struct {
 	HeaderValue func(*Parser, []byte, int)
 	// http 解析完成之后的回调函数
 	HeadersComplete func(*Parser, int)
+	// 请求有Expect: 100-continue, 在HeadersComplete之后回调
+	// 服务端可以在这里回复100 Continue, 或者调用p.Abort终止解析之后回复417, 413
+	ExpectContinue func(*Parser, int)
 	// body的回调函数
 	Body func(*Parser, []byte, int)
+	// chunked模式下, 解析完chunk-size那一行之后的回调函数, 参数是chunk的大小
+	// 最后一个大小为0的chunk也会回调
+	ChunkHeader func(*Parser, int64, int)
+	// chunk扩展的名字
+	// 解析一个chunk扩展时, ChunkExtensionName回调可能会多次调用
+	ChunkExtensionName func(*Parser, []byte, int)
+	// chunk扩展的值, token或者quoted-string, quoted-string会带上两边的双引号
+	// 解析一个chunk扩展时, ChunkExtensionValue回调可能会多次调用
+	ChunkExtensionValue func(*Parser, []byte, int)
+	// 一个chunk结束, 最后一个chunk在trailer解析完之后回调
+	ChunkComplete func(*Parser, int)
+	// trailer field 回调函数, 没有设置时, trailer field交给HeaderField回调
+	TrailerField func(*Parser, []byte, int)
+	// trailer value 回调函数, 没有设置时, trailer value交给HeaderValue回调
+	TrailerValue func(*Parser, []byte, int)
+	// chunked包的trailer解析完成之后的回调函数, 没有trailer也会回调
+	TrailersComplete func(*Parser, int)
 	// 所有消息成功解析
 	MessageComplete func(*Parser, int)
 }
@@ -73,9 +97,15 @@ const (
 	reqHTTPVersionDot
 	// HTTP-Version中的minor
 	reqHTTPVersionMinor
+	// HTTP-Version后面的\r
+	reqHTTPVersionEnd
 
 	// request-line \r的位置
 	reqRequestLineAlomstDone
+	// HTTP/0.9的请求行没有版本号, 直接以\r\n结束
+	reqHTTP09
+	// HTTP/0.9请求行\r的位置
+	reqHTTP09AlmostDone
 	// response状态
 	startRsp
 	// HTTP
@@ -107,6 +137,8 @@ const (
 	headerValueStartOWS
 	// 快要离开http value后面的OWS
 	headerValueOWS
+	// http value的\n后面, 检查是不是obs-fold
+	headerValueLF
 	// 进入http body
 	httpBody
 	// 开始进入到chunked 数字解析
@@ -115,8 +147,22 @@ const (
 	chunkedSize
 	// chunked size结束
 	chunkedSizeAlmostDone
-	// chunked ext
-	chunkedExt
+	// chunk-size或者chunk扩展后面的BWS
+	chunkedExtBWS
+	// chunk扩展名字前面的BWS
+	chunkedExtStart
+	// chunk扩展的名字
+	chunkedExtName
+	// chunk扩展名字后面的BWS
+	chunkedExtNameBWS
+	// chunk扩展的值前面的BWS
+	chunkedExtValueStart
+	// chunk扩展的值, token
+	chunkedExtValue
+	// chunk扩展的值, quoted-string
+	chunkedExtQuoted
+	// chunk扩展的值, quoted-string里面的'\\'
+	chunkedExtQuotedPair
 	// chunked data
 	chunkedData
 	// chunked 检查是否真的结束
@@ -129,10 +175,13 @@ const (
 	bodyIdentityEOF
 	// 解析结束
 	messageDone
+	// 连接不能复用, 后面只允许出现\r\n
+	closed
 )
 
 // debug使用
 var stateTab = []string{
+	dead:                     "dead",
 	startReq:                 "startReq",
 	reqMethodAfterSP:         "reqMethodAfterSP",
 	reqURL:                   "reqURL",
@@ -141,7 +190,10 @@ var stateTab = []string{
 	reqHTTPVersionMajor:      "reqHTTPVersionMajor",
 	reqHTTPVersionDot:        "reqHTTPVersionDot",
 	reqHTTPVersionMinor:      "reqHTTPVersionMinor",
+	reqHTTPVersionEnd:        "reqHTTPVersionEnd",
 	reqRequestLineAlomstDone: "reqRequestLineAlomstDone",
+	reqHTTP09:                "reqHTTP09",
+	reqHTTP09AlmostDone:      "reqHTTP09AlmostDone",
 	startRsp:                 "startRsp",
 	rspHTTP:                  "rspHTTP",
 	rspHTTPVersionNum:        "rspHTTPVersionNum",
@@ -157,17 +209,26 @@ var stateTab = []string{
 	headerValue:              "headerValue",
 	headerValueStartOWS:      "headerValueStartOWS",
 	headerValueOWS:           "headerValueOWS",
+	headerValueLF:            "headerValueLF",
 	httpBody:                 "httpBody",
 	chunkedSizeStart:         "chunkedSizeStart",
 	chunkedSize:              "chunkedSize",
 	chunkedSizeAlmostDone:    "chunkedSizeAlmostDone",
-	chunkedExt:               "chunkedExt",
+	chunkedExtBWS:            "chunkedExtBWS",
+	chunkedExtStart:          "chunkedExtStart",
+	chunkedExtName:           "chunkedExtName",
+	chunkedExtNameBWS:        "chunkedExtNameBWS",
+	chunkedExtValueStart:     "chunkedExtValueStart",
+	chunkedExtValue:          "chunkedExtValue",
+	chunkedExtQuoted:         "chunkedExtQuoted",
+	chunkedExtQuotedPair:     "chunkedExtQuotedPair",
 	chunkedData:              "chunkedData",
 	chunkedDataAlmostDone:    "chunkedDataAlmostDone",
 	chunkedDataDone:          "chunkedDataDone",
 	messageAlmostDone:        "messageAlmostDone",
 	bodyIdentityEOF:          "bodyIdentityEOF",
 	messageDone:              "messageDone",
+	closed:                   "closed",
 }
 
 type headerState uint8
@@ -177,4 +238,7 @@ const (
 	hContentLength
 	hTransferEncoding
 	hConnection
+	hTrailer
+	hExpect
+	hUpgrade
 )
@@ -0,0 +1,118 @@
+// Copyright 2021 guonaihong. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httparser
+
+// StatusClass 状态码的类别, 由状态码的第1位数字决定
+// https://www.rfc-editor.org/rfc/rfc9110#section-15
+type StatusClass uint8
+
+const (
+	// StatusClassUnknown 没有状态码或者不在100-599的范围
+	StatusClassUnknown StatusClass = iota
+	// StatusInformational 1xx
+	StatusInformational
+	// StatusSuccessful 2xx
+	StatusSuccessful
+	// StatusRedirection 3xx
+	StatusRedirection
+	// StatusClientError 4xx
+	StatusClientError
+	// StatusServerError 5xx
+	StatusServerError
+)
+
+// StatusClass 返回响应包状态码的类别
+func (p *Parser) StatusClass() StatusClass {
+	if p.StatusCode < 100 || p.StatusCode > 599 {
+		return StatusClassUnknown
+	}
+	return StatusClass(p.StatusCode / 100)
+}
+
+// 状态码和状态短语的对应关系
+// https://www.iana.org/assignments/http-status-codes/http-status-codes.xhtml
+var statusText = map[int]string{
+	100: "Continue",
+	101: "Switching Protocols",
+	102: "Processing",
+	103: "Early Hints",
+
+	200: "OK",
+	201: "Created",
+	202: "Accepted",
+	203: "Non-Authoritative Information",
+	204: "No Content",
+	205: "Reset Content",
+	206: "Partial Content",
+	207: "Multi-Status",
+	208: "Already Reported",
+	226: "IM Used",
+
+	300: "Multiple Choices",
+	301: "Moved Permanently",
+	302: "Found",
+	303: "See Other",
+	304: "Not Modified",
+	305: "Use Proxy",
+	307: "Temporary Redirect",
+	308: "Permanent Redirect",
+
+	400: "Bad Request",
+	401: "Unauthorized",
+	402: "Payment Required",
+	403: "Forbidden",
+	404: "Not Found",
+	405: "Method Not Allowed",
+	406: "Not Acceptable",
+	407: "Proxy Authentication Required",
+	408: "Request Timeout",
+	409: "Conflict",
+	410: "Gone",
+	411: "Length Required",
+	412: "Precondition Failed",
+	413: "Content Too Large",
+	414: "URI Too Long",
+	415: "Unsupported Media Type",
+	416: "Range Not Satisfiable",
+	417: "Expectation Failed",
+	418: "I'm a teapot",
+	421: "Misdirected Request",
+	422: "Unprocessable Content",
+	423: "Locked",
+	424: "Failed Dependency",
+	425: "Too Early",
+	426: "Upgrade Required",
+	428: "Precondition Required",
+	429: "Too Many Requests",
+	431: "Request Header Fields Too Large",
+	451: "Unavailable For Legal Reasons",
+
+	500: "Internal Server Error",
+	501: "Not Implemented",
+	502: "Bad Gateway",
+	503: "Service Unavailable",
+	504: "Gateway Timeout",
+	505: "HTTP Version Not Supported",
+	506: "Variant Also Negotiates",
+	507: "Insufficient Storage",
+	508: "Loop Detected",
+	510: "Not Extended",
+	511: "Network Authentication Required",
+}
+
+// StatusText 返回状态码对应的状态短语, 不认识的状态码返回空字符串
+func StatusText(code int) string {
+	return statusText[code]
+}
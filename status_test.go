@@ -0,0 +1,60 @@
+package httparser
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_StatusClass(t *testing.T) {
+	for _, tc := range []struct {
+		rsp   string
+		code  uint16
+		class StatusClass
+	}{
+		{rsp: "HTTP/1.1 101 Switching Protocols\r\n\r\n", code: 101, class: StatusInformational},
+		{rsp: "HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n", code: 200, class: StatusSuccessful},
+		{rsp: "HTTP/1.1 304 Not Modified\r\n\r\n", code: 304, class: StatusRedirection},
+		{rsp: "HTTP/1.1 404\r\nContent-Length: 0\r\n\r\n", code: 404, class: StatusClientError},
+		{rsp: "HTTP/1.1 503 \r\nContent-Length: 0\r\n\r\n", code: 503, class: StatusServerError},
+		{rsp: "HTTP/1.1 600 Unknown\r\nContent-Length: 0\r\n\r\n", code: 600, class: StatusClassUnknown},
+	} {
+		p := New(RESPONSE)
+		if _, err := p.Execute(&Setting{}, []byte(tc.rsp)); err != nil {
+			t.Fatalf("%q: %v", tc.rsp, err)
+		}
+
+		if p.StatusCode != tc.code || p.StatusClass() != tc.class {
+			t.Errorf("%q: code is %d, class is %d", tc.rsp, p.StatusCode, p.StatusClass())
+		}
+	}
+
+	// 状态码必须是3位数字
+	for _, line := range []string{
+		"HTTP/1.1 99999999 OK",
+		"HTTP/1.1 2000 OK",
+		"HTTP/1.1 20 OK",
+		"HTTP/1.1 099 OK",
+		"HTTP/1.1 2x0 OK",
+		"HTTP/1.1 OK",
+	} {
+		p := New(RESPONSE)
+		_, err := p.Execute(&Setting{}, []byte(line+"\r\n\r\n"))
+		if !errors.Is(err, ErrStatusCode) {
+			t.Errorf("%q: err is %v, expect %v", line, err, ErrStatusCode)
+		}
+	}
+}
+
+func Test_StatusText(t *testing.T) {
+	for code, text := range map[int]string{
+		100: "Continue",
+		200: "OK",
+		404: "Not Found",
+		505: "HTTP Version Not Supported",
+		999: "",
+	} {
+		if got := StatusText(code); got != text {
+			t.Errorf("%d: text is %q, expect %q", code, got, text)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package httparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Token(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		v := token[i]
+		switch {
+		case i >= '0' && i <= '9', i >= 'a' && i <= 'z', i >= 'A' && i <= 'Z',
+			i < 0x80 && strings.IndexByte("!#$%&'*+-.^_`|~", byte(i)) != -1:
+			if int(v) != i {
+				t.Fatalf("fail:%c", i)
+			}
+		default:
+			if v != 0 {
+				t.Fatalf("fail:%c", i)
+			}
+		}
+	}
+}
@@ -29,16 +29,16 @@ func Test_Twobuf(t *testing.T) {
 			if i >= 2 {
 				start := i * blockSize
 
-				if string(tb.All(n)) != v[start-offset:min(int32(start+blockSize), int32(len(v)))] {
+				if string(tb.All(n)) != v[start-offset:min(int64(start+blockSize), int64(len(v)))] {
 					t.Error("not equal")
 				}
-				// assert.Equal(t, string(tb.All(n)), v[start-offset:min(int32(start+blockSize), int32(len(v)))])
+				// assert.Equal(t, string(tb.All(n)), v[start-offset:min(int64(start+blockSize), int64(len(v)))])
 			} else {
 				start := i * blockSize
-				if string(tb.All(n)) != v[start:min(int32(start+blockSize), int32(len(v)))] {
+				if string(tb.All(n)) != v[start:min(int64(start+blockSize), int64(len(v)))] {
 					t.Error("not equal")
 				}
-				// assert.Equal(t, string(tb.All(n)), v[start:min(int32(start+blockSize), int32(len(v)))])
+				// assert.Equal(t, string(tb.All(n)), v[start:min(int64(start+blockSize), int64(len(v)))])
 			}
 
 			if i != 0 {
@@ -0,0 +1,90 @@
+// Copyright 2021 guonaihong. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httparser
+
+import "strings"
+
+// https://www.rfc-editor.org/rfc/rfc9110#section-7.8
+// Upgrade          = #protocol
+// protocol         = protocol-name ["/" protocol-version]
+// protocol-name    = token
+// protocol-version = token
+
+// UpgradeProtocols 返回Upgrade头部里面的协议, 在HeadersComplete之后使用
+// 请求包是客户端提供的协议, 按照客户端的优先级排列, 101响应是服务端选择的协议
+// 返回的slice在下一个消息开始之前有效
+func (p *Parser) UpgradeProtocols() []string {
+	return p.upgrades
+}
+
+// AcceptUpgrade 服务端选择一个协议, 只能在请求的HeadersComplete回调里面调用
+// proto没有版本号时只比较protocol-name, 比如websocket, h2c
+// 客户端没有提供这个协议时返回false, 并且p.Upgrade会被设置为false, 解析器不会升级, 后面的数据还是按照http解析
+func (p *Parser) AcceptUpgrade(proto string) bool {
+	if !p.Upgrade {
+		return false
+	}
+
+	for _, offer := range p.upgrades {
+		if upgradeMatch(offer, proto) {
+			return true
+		}
+	}
+
+	p.Upgrade = false
+	return false
+}
+
+// OfferUpgrade 客户端在解析响应之前设置请求里面提供的协议, 和p.Method = HEAD的用法一样
+// 101响应的Upgrade头部里面有没有提供过的协议时, Execute返回ErrUpgradeProtocol
+// 不带参数调用会清空之前提供的协议, 不再检查101响应
+func (p *Parser) OfferUpgrade(protos ...string) {
+	p.offers = append(p.offers[:0], protos...)
+}
+
+// checkUpgrade 检查101响应选择的协议, 返回没有提供过的协议
+func (p *Parser) checkUpgrade() (string, bool) {
+	if len(p.upgrades) == 0 {
+		return "", false
+	}
+
+	for _, proto := range p.upgrades {
+		offered := false
+		for _, offer := range p.offers {
+			if upgradeMatch(proto, offer) {
+				offered = true
+				break
+			}
+		}
+
+		if !offered {
+			return proto, false
+		}
+	}
+	return "", true
+}
+
+// upgradeMatch protocol-name不区分大小写, proto没有版本号时只比较protocol-name
+func upgradeMatch(offer, proto string) bool {
+	if strings.EqualFold(offer, proto) {
+		return true
+	}
+
+	if strings.IndexByte(proto, '/') != -1 {
+		return false
+	}
+
+	return len(offer) > len(proto) && offer[len(proto)] == '/' && strings.EqualFold(offer[:len(proto)], proto)
+}
@@ -0,0 +1,434 @@
+// Copyright 2021 guonaihong. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httparser
+
+// URLField URL里面的字段
+type URLField uint8
+
+const (
+	// URLSchema http, https
+	URLSchema URLField = iota
+	// URLHost 主机名, ipv6地址不包含[]
+	URLHost
+	// URLPort 端口
+	URLPort
+	// URLPath 路径
+	URLPath
+	// URLQuery ?后面的查询字符串, 不包含?
+	URLQuery
+	// URLFragment #后面的内容, 不包含#
+	URLFragment
+	// URLUserInfo @前面的用户信息
+	URLUserInfo
+	// URLMax 字段的个数
+	URLMax
+)
+
+// URLFieldData 字段在buf里面的偏移量和长度
+type URLFieldData struct {
+	Off int
+	Len int
+}
+
+// URL ParseURL的结果, 只记录偏移量和长度, 不拷贝数据
+type URL struct {
+	FieldSet  uint16               // 1 << URLField 表示对应的字段存在
+	Port      uint16               // 转成数字的端口, 没有端口时为0
+	FieldData [URLMax]URLFieldData // 每个字段在buf里面的位置
+}
+
+// Has 字段是否存在
+func (u *URL) Has(f URLField) bool {
+	return u.FieldSet&(1<<f) != 0
+}
+
+// Get 从ParseURL传入的buf里面取出字段的数据, 字段不存在时返回nil
+func (u *URL) Get(buf []byte, f URLField) []byte {
+	if !u.Has(f) {
+		return nil
+	}
+
+	d := u.FieldData[f]
+	return buf[d.Off : d.Off+d.Len]
+}
+
+type urlState uint8
+
+const (
+	urlDead urlState = iota + 1
+	urlStart
+	urlSchema
+	urlSchemaSlash
+	urlSchemaSlashSlash
+	urlServerStart
+	urlServer
+	urlServerWithAt
+	urlPath
+	urlQueryStart
+	urlQuery
+	urlFragmentStart
+	urlFragment
+)
+
+type hostState uint8
+
+const (
+	hostDead hostState = iota + 1
+	hostUserInfoStart
+	hostUserInfo
+	hostStart
+	hostV6Start
+	hostV6
+	hostV6End
+	hostV6ZoneStart
+	hostV6Zone
+	hostName
+	hostPortStart
+	hostPort
+)
+
+func isAlpha(c byte) bool {
+	c |= 0x20
+	return c >= 'a' && c <= 'z'
+}
+
+func isNum(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlphaNum(c byte) bool {
+	return isAlpha(c) || isNum(c)
+}
+
+// isMark unreserved里面除了字母和数字的字符, 再加上rfc 2396里面的mark
+func isMark(c byte) bool {
+	switch c {
+	case '-', '_', '.', '!', '~', '*', '\'', '(', ')':
+		return true
+	}
+	return false
+}
+
+func isUserInfoChar(c byte) bool {
+	if isAlphaNum(c) || isMark(c) {
+		return true
+	}
+
+	switch c {
+	case '%', ';', ':', '&', '=', '+', '$', ',':
+		return true
+	}
+	return false
+}
+
+func isHostChar(c byte) bool {
+	return isAlphaNum(c) || c == '.' || c == '-' || c == '_'
+}
+
+// isURLChar 可见字符里面除了'#'和'?', 兼容utf-8的url, 不检查0x80以上的字符
+func isURLChar(c byte) bool {
+	if c >= 0x80 {
+		return true
+	}
+	return c > ' ' && c < 0x7f && c != '#' && c != '?'
+}
+
+// parseURLChar 移植自http-parser的parse_url_char
+func parseURLChar(s urlState, c byte) urlState {
+	if c == ' ' || c == '\r' || c == '\n' || c == '\t' || c == '\f' {
+		return urlDead
+	}
+
+	switch s {
+	case urlStart:
+		// 代理请求是absolute-form, 以schema开头
+		// CONNECT以外的方法, 都是以'/'或者'*'开头
+		if c == '/' || c == '*' {
+			return urlPath
+		}
+
+		if isAlpha(c) {
+			return urlSchema
+		}
+
+	case urlSchema:
+		if isAlpha(c) {
+			return s
+		}
+
+		if c == ':' {
+			return urlSchemaSlash
+		}
+
+	case urlSchemaSlash:
+		if c == '/' {
+			return urlSchemaSlashSlash
+		}
+
+	case urlSchemaSlashSlash:
+		if c == '/' {
+			return urlServerStart
+		}
+
+	case urlServerWithAt, urlServerStart, urlServer:
+		if s == urlServerWithAt && c == '@' {
+			return urlDead
+		}
+
+		switch c {
+		case '/':
+			return urlPath
+		case '?':
+			return urlQueryStart
+		case '@':
+			return urlServerWithAt
+		}
+
+		if isUserInfoChar(c) || c == '[' || c == ']' {
+			return urlServer
+		}
+
+	case urlPath:
+		if isURLChar(c) {
+			return s
+		}
+
+		switch c {
+		case '?':
+			return urlQueryStart
+		case '#':
+			return urlFragmentStart
+		}
+
+	case urlQueryStart, urlQuery:
+		if isURLChar(c) || c == '?' {
+			return urlQuery
+		}
+
+		if c == '#' {
+			return urlFragmentStart
+		}
+
+	case urlFragmentStart:
+		if isURLChar(c) || c == '?' {
+			return urlFragment
+		}
+
+		if c == '#' {
+			return s
+		}
+
+	case urlFragment:
+		if isURLChar(c) || c == '?' || c == '#' {
+			return s
+		}
+	}
+
+	return urlDead
+}
+
+// parseHostChar 移植自http-parser的http_parse_host_char
+func parseHostChar(s hostState, c byte) hostState {
+	switch s {
+	case hostUserInfo, hostUserInfoStart:
+		if c == '@' {
+			return hostStart
+		}
+
+		if isUserInfoChar(c) {
+			return hostUserInfo
+		}
+
+	case hostStart:
+		if c == '[' {
+			return hostV6Start
+		}
+
+		if isHostChar(c) {
+			return hostName
+		}
+
+	case hostName, hostV6End:
+		if s == hostName && isHostChar(c) {
+			return hostName
+		}
+
+		if c == ':' {
+			return hostPortStart
+		}
+
+	case hostV6, hostV6Start:
+		if s == hostV6 && c == ']' {
+			return hostV6End
+		}
+
+		if unhex[c] != -1 || c == ':' || c == '.' {
+			return hostV6
+		}
+
+		if s == hostV6 && c == '%' {
+			return hostV6ZoneStart
+		}
+
+	case hostV6Zone, hostV6ZoneStart:
+		if s == hostV6Zone && c == ']' {
+			return hostV6End
+		}
+
+		// https://www.rfc-editor.org/rfc/rfc6874#section-2
+		// ZoneID = 1*( unreserved / pct-encoded )
+		if isAlphaNum(c) || c == '%' || c == '.' || c == '-' || c == '_' || c == '~' {
+			return hostV6Zone
+		}
+
+	case hostPort, hostPortStart:
+		if isNum(c) {
+			return hostPort
+		}
+	}
+
+	return hostDead
+}
+
+// parseHost 把URLHost字段再拆成userinfo, host, port
+func (u *URL) parseHost(buf []byte, foundAt bool) error {
+	host := &u.FieldData[URLHost]
+	end := host.Off + host.Len
+	host.Len = 0
+
+	s := hostStart
+	if foundAt {
+		s = hostUserInfoStart
+	}
+
+	for i := host.Off; i < end; i++ {
+		newState := parseHostChar(s, buf[i])
+		switch newState {
+		case hostDead:
+			return ErrInvalidURL
+		case hostName, hostV6:
+			if s != newState {
+				host.Off = i
+			}
+			host.Len++
+		case hostV6ZoneStart, hostV6Zone:
+			host.Len++
+		case hostPort:
+			if s != hostPort {
+				u.FieldData[URLPort] = URLFieldData{Off: i}
+				u.FieldSet |= 1 << URLPort
+			}
+			u.FieldData[URLPort].Len++
+		case hostUserInfo:
+			if s != hostUserInfo {
+				u.FieldData[URLUserInfo] = URLFieldData{Off: i}
+				u.FieldSet |= 1 << URLUserInfo
+			}
+			u.FieldData[URLUserInfo].Len++
+		}
+		s = newState
+	}
+
+	// 不能在这些状态结束
+	switch s {
+	case hostStart, hostV6Start, hostV6, hostV6ZoneStart, hostV6Zone,
+		hostPortStart, hostUserInfo, hostUserInfoStart:
+		return ErrInvalidURL
+	}
+
+	return nil
+}
+
+// ParseURL 解析request-target, 移植自http-parser的http_parser_parse_url
+// 支持origin-form, absolute-form, asterisk-form, isConnect为true时只支持authority-form(host:port)
+// https://www.rfc-editor.org/rfc/rfc9112#section-3.2
+// 返回的URL只记录每个字段在buf里面的位置, 使用URL.Get取出字段
+func ParseURL(buf []byte, isConnect bool) (u URL, err error) {
+	if len(buf) == 0 {
+		return u, ErrInvalidURL
+	}
+
+	s := urlStart
+	if isConnect {
+		s = urlServerStart
+	}
+
+	foundAt := false
+	oldField := URLMax
+	for i, c := range buf {
+		s = parseURLChar(s, c)
+
+		var field URLField
+		switch s {
+		case urlDead:
+			return u, ErrInvalidURL
+		case urlSchemaSlash, urlSchemaSlashSlash, urlServerStart, urlQueryStart, urlFragmentStart:
+			// 跳过分隔符号
+			continue
+		case urlSchema:
+			field = URLSchema
+		case urlServerWithAt:
+			foundAt = true
+			field = URLHost
+		case urlServer:
+			field = URLHost
+		case urlPath:
+			field = URLPath
+		case urlQuery:
+			field = URLQuery
+		case urlFragment:
+			field = URLFragment
+		}
+
+		if field == oldField {
+			u.FieldData[field].Len++
+			continue
+		}
+
+		u.FieldData[field] = URLFieldData{Off: i, Len: 1}
+		u.FieldSet |= 1 << field
+		oldField = field
+	}
+
+	// 有schema一定要有host, 比如http:///toto是错误的
+	if u.Has(URLSchema) && !u.Has(URLHost) {
+		return u, ErrInvalidURL
+	}
+
+	if u.Has(URLHost) {
+		if err = u.parseHost(buf, foundAt); err != nil {
+			return u, err
+		}
+	}
+
+	// CONNECT只能是host:port
+	if isConnect && u.FieldSet != 1<<URLHost|1<<URLPort {
+		return u, ErrInvalidURL
+	}
+
+	if u.Has(URLPort) {
+		// 端口已经检查过, 都是数字
+		v := 0
+		for _, c := range u.Get(buf, URLPort) {
+			v = v*10 + int(c-'0')
+			if v > 0xffff {
+				return u, ErrInvalidURL
+			}
+		}
+		u.Port = uint16(v)
+	}
+
+	return u, nil
+}